@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// accessReviewWorkers limits concurrent access review requests.
+const accessReviewWorkers = 8
+
+// CanI checks if current user is allowed to perform verb on secrets
+// in namespace using SelfSubjectAccessReview. Empty name checks access
+// to all secrets in namespace.
+func (k *K8SClient) CanI(ctx context.Context, namespace, verb, name string) (bool, error) {
+	return k.canI(ctx, "secrets", namespace, verb, name)
+}
+
+// CanIConfigMap is CanI for ConfigMaps.
+func (k *K8SClient) CanIConfigMap(ctx context.Context, namespace, verb, name string) (bool, error) {
+	return k.canI(ctx, "configmaps", namespace, verb, name)
+}
+
+func (k *K8SClient) canI(ctx context.Context, resource, namespace, verb, name string) (bool, error) {
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      verb,
+				Resource:  resource,
+				Name:      name,
+			},
+		},
+	}
+	res, err := k.clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("review access to %s %s in namespace '%s': %w", verb, resource, namespace, err)
+	}
+	return res.Status.Allowed, nil
+}
+
+// FilterSecretNamespaces returns namespaces where user can list secrets.
+func (k *K8SClient) FilterSecretNamespaces(ctx context.Context, namespaces []string) ([]string, error) {
+	return k.filterNamespaces(ctx, "secrets", namespaces)
+}
+
+// FilterConfigMapNamespaces returns namespaces where user can list ConfigMaps.
+func (k *K8SClient) FilterConfigMapNamespaces(ctx context.Context, namespaces []string) ([]string, error) {
+	return k.filterNamespaces(ctx, "configmaps", namespaces)
+}
+
+func (k *K8SClient) filterNamespaces(ctx context.Context, resource string, namespaces []string) ([]string, error) {
+	allowed := make([]bool, len(namespaces))
+	errs := make([]error, len(namespaces))
+	sem := make(chan struct{}, accessReviewWorkers)
+	var wg sync.WaitGroup
+	for i, ns := range namespaces {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			allowed[i], errs[i] = k.canI(ctx, resource, ns, "list", "")
+		}()
+	}
+	wg.Wait()
+
+	res := make([]string, 0, len(namespaces))
+	for i, ns := range namespaces {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if allowed[i] {
+			res = append(res, ns)
+		}
+	}
+	return res, nil
+}
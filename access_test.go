@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newAccessReviewClient creates client which allows access
+// according to allow function.
+func newAccessReviewClient(allow func(attrs *authorizationv1.ResourceAttributes) bool) *K8SClient {
+	fakeClientset := fake.NewSimpleClientset()
+	fakeClientset.PrependReactor("create", "selfsubjectaccessreviews",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+			review.Status.Allowed = allow(review.Spec.ResourceAttributes)
+			return true, review, nil
+		})
+	return &K8SClient{clientset: fakeClientset}
+}
+
+func TestCanI(t *testing.T) {
+	client := newAccessReviewClient(func(attrs *authorizationv1.ResourceAttributes) bool {
+		return attrs.Verb == "update" && attrs.Name == "mysecret" && attrs.Resource == "secrets"
+	})
+	ctx := context.Background()
+
+	allowed, err := client.CanI(ctx, "default", "update", "mysecret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !allowed {
+		t.Error("expected update to be allowed")
+	}
+
+	allowed, err = client.CanI(ctx, "default", "update", "other")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if allowed {
+		t.Error("expected update of other secret to be denied")
+	}
+}
+
+func TestFilterSecretNamespaces(t *testing.T) {
+	client := newAccessReviewClient(func(attrs *authorizationv1.ResourceAttributes) bool {
+		return attrs.Verb == "list" && attrs.Namespace != "kube-system"
+	})
+
+	namespaces, err := client.FilterSecretNamespaces(context.Background(),
+		[]string{"default", "kube-system", "team-a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(namespaces, []string{"default", "team-a"}) {
+		t.Errorf("unexpected namespaces: %v", namespaces)
+	}
+}
+
+func TestFilterSecretNamespaces_Error(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset()
+	fakeClientset.PrependReactor("create", "selfsubjectaccessreviews",
+		func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("not supported")
+		})
+	client := &K8SClient{clientset: fakeClientset}
+
+	_, err := client.FilterSecretNamespaces(context.Background(), []string{"default"})
+	if err == nil {
+		t.Error("expected error, got nil")
+	}
+}
@@ -0,0 +1,986 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/manifoldco/promptui"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Actions available for a selected secret key.
+const (
+	actionEdit     = "Edit value"
+	actionGenerate = "Generate value"
+	actionRename   = "Rename key"
+	actionDelete   = "Delete key"
+)
+
+var keyActions = []string{actionEdit, actionGenerate, actionRename, actionDelete}
+
+// Actions available for a Helm release secret.
+const (
+	actionViewRelease = "View release (read-only)"
+	actionSelectKey   = "Select key"
+)
+
+var helmReleaseActions = []string{actionViewRelease, actionSelectKey}
+
+// confirm asks user to confirm saving changes to the secret. Contexts
+// with typed confirmation rule require typing the secret name and ask
+// for an optional reason of the change, unless it's set by flag.
+func (a *app) confirm(label, secret string) bool {
+	if !a.rules.TypedConfirmation {
+		return confirm(label)
+	}
+	if !typedConfirm(label, secret) {
+		fmt.Println("Save cancelled")
+		return false
+	}
+	if a.cfg.Reason == "" {
+		a.k8s.SetReason(promptString("Reason for the change (optional)", ""))
+	}
+	return true
+}
+
+func (a *app) editKey(namespace, secret, key string, current *Secret) {
+	a.checkUpdateAccess(namespace, secret, current)
+	originData := current.Data[key]
+	editedData, ok := a.editValue(key, originData)
+	if !ok {
+		return
+	}
+	data := maps.Clone(current.Data)
+	data[key] = editedData
+	if !a.checkSize(secret, data) {
+		return
+	}
+	if a.cfg.SealCert != "" {
+		a.sealSecret(namespace, secret, data)
+		return
+	}
+
+	consumers, _ := a.showConsumers(namespace, secret)
+	if !a.confirm(fmt.Sprintf("Apply changes to secret '%s/%s' key '%s'", namespace, secret, key), secret) {
+		return
+	}
+
+	if !a.save(namespace, secret, current, data, func(ctx context.Context) error {
+		return a.k8s.SaveSecret(ctx, namespace, secret, key, editedData)
+	}) {
+		return
+	}
+
+	fmt.Printf("Secret '%s' in namespace '%s' updated successfully.\n", secret, namespace)
+	a.restartConsumers(namespace, consumers)
+}
+
+// editConfigMapKey edits value of the ConfigMap key with the same diff
+// and confirmation as secrets. Values are not secret, so they are shown
+// unmasked, and base64 values are expected, so they are not flagged.
+func (a *app) editConfigMapKey(namespace, name, key string, cm *ConfigMap) {
+	if cm.Immutable {
+		fatalf("ConfigMap '%s' in namespace '%s' is immutable", name, namespace)
+	}
+	a.checkConfigMapAccess(namespace, name)
+	originData := cm.Values()[key]
+	editedData, ok := editData(a.editor, key, originData)
+	if !ok {
+		return
+	}
+	editedData = a.keepLineStyle(key, originData, editedData)
+	if slices.Equal(originData, editedData) {
+		fmt.Println("No changes detected, exiting.")
+		return
+	}
+	printDiff(MaskNone, originData, editedData)
+	if !cm.IsBinary(key) && !utf8.Valid(editedData) {
+		fmt.Printf("Edited value of key '%s' is not valid UTF-8, it will be moved to binaryData.\n", key)
+	}
+	data := cm.Values()
+	data[key] = editedData
+	if !a.checkSize(name, data) {
+		return
+	}
+
+	if !a.confirm(fmt.Sprintf("Apply changes to configmap '%s/%s' key '%s'", namespace, name, key), name) {
+		return
+	}
+	_, err := withTimeoutCtx(func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, a.k8s.SaveConfigMap(ctx, namespace, name, key, editedData)
+	})
+	if err != nil {
+		fatalf("Error saving configmap '%s' in namespace '%s': %v", name, namespace, err)
+	}
+	fmt.Printf("ConfigMap '%s' in namespace '%s' updated successfully.\n", name, namespace)
+}
+
+// checkConfigMapAccess exits if user is not allowed to update the
+// ConfigMap or the context is read-only.
+func (a *app) checkConfigMapAccess(namespace, name string) {
+	if a.rules.ReadOnly {
+		fatalf("Context '%s' is read-only", a.k8s.Context())
+	}
+	allowed, err := withTimeoutCtx(func(ctx context.Context) (bool, error) {
+		return a.k8s.CanIConfigMap(ctx, namespace, "update", name)
+	})
+	if err != nil {
+		fmt.Printf("Warning: unable to check update permission: %v\n", err)
+	} else if !allowed {
+		fatalf("Not allowed to update configmap '%s' in namespace '%s'", name, namespace)
+	}
+}
+
+// editValue opens value in the editor and prints the diff. Values
+// encoded to base64 twice are offered to be edited decoded and encoded
+// back, and edited values which look encoded are offered to be decoded.
+// Changed line endings are restored according to the key policy.
+func (a *app) editValue(key string, originData []byte) ([]byte, bool) {
+	layer, decodeLayer := originData, false
+	decoded, encodedTwice := DecodeBase64Layer(originData)
+	if encodedTwice {
+		fmt.Printf("Warning: value of key '%s' looks base64 encoded twice.\n", key)
+		if decodeLayer = promptConfirm("Edit decoded value and encode it back on save"); decodeLayer {
+			layer = decoded
+		}
+	}
+
+	editedData, ok := editData(a.editor, key, layer)
+	if !ok {
+		return nil, false
+	}
+	if decoded, ok := DecodeBase64Layer(editedData); ok && !encodedTwice {
+		fmt.Printf("Warning: edited value of key '%s' looks base64 encoded, Kubernetes encodes secret data itself.\n", key)
+		if promptConfirm("Save decoded value instead") {
+			editedData = decoded
+		}
+	}
+	editedData = a.keepLineStyle(key, layer, editedData)
+	if slices.Equal(layer, editedData) {
+		fmt.Println("No changes detected, exiting.")
+		return nil, false
+	}
+	printDiff(a.cfg.Mask, layer, editedData)
+
+	if decodeLayer {
+		return EncodeBase64Layer(editedData, originData), true
+	}
+	return editedData, true
+}
+
+// keepLineStyle checks if editing changed trailing newline or line
+// endings of the value and restores the original style according
+// to the policy configured for the key.
+func (a *app) keepLineStyle(key string, originData, editedData []byte) []byte {
+	origin, edited := DetectLineStyle(originData), DetectLineStyle(editedData)
+	if len(originData) == 0 || origin == edited {
+		return editedData
+	}
+	switch a.cfg.LineEndingPolicy(key) {
+	case LineEndingIgnore:
+		return editedData
+	case LineEndingKeep:
+		fmt.Printf("Restored line endings of key '%s': %s\n", key, origin)
+		return origin.Apply(editedData)
+	}
+	fmt.Printf("Warning: line endings of key '%s' changed: %s -> %s\n", key, origin, edited)
+	if !promptConfirm("Keep original line endings") {
+		return editedData
+	}
+	return origin.Apply(editedData)
+}
+
+// promptGenerateOptions asks user for generator kind and its main option.
+func (a *app) promptGenerateOptions(secret string) GenerateOptions {
+	kinds := make([]string, len(GeneratorKinds))
+	for i, k := range GeneratorKinds {
+		kinds[i] = string(k)
+	}
+	opts := GenerateOptions{Kind: GeneratorKind(selectAction("Value kind", kinds))}
+	switch opts.Kind {
+	case GeneratePassword:
+		opts.Length = promptInt("Password length", 32)
+		charsets := promptString("Charsets (lower, upper, digits, symbols)", "lower,upper,digits")
+		for _, c := range strings.Split(charsets, ",") {
+			opts.Charsets = append(opts.Charsets, strings.TrimSpace(c))
+		}
+	case GenerateHex, GenerateBase64:
+		opts.Bytes = promptInt("Random bytes", 32)
+	case GenerateTLS:
+		opts.CommonName = promptString("Certificate common name", secret)
+	}
+	return opts
+}
+
+// generateKey generates value of the key and saves it after masked
+// diff and confirmation. Key pairs are saved to two keys.
+func (a *app) generateKey(namespace, secret, key string, current *Secret, opts GenerateOptions) {
+	a.checkUpdateAccess(namespace, secret, current)
+	values, err := Generate(key, opts)
+	if err != nil {
+		fatalf("Error generating value: %v", err)
+	}
+
+	keys := GeneratedKeys(opts.Kind, key)
+	data := maps.Clone(current.Data)
+	if data == nil {
+		data = make(SecretData)
+	}
+	for _, k := range keys {
+		fmt.Printf("Key '%s':\n", k)
+		printDiff(PreviewMask(a.cfg.Mask), current.Data[k], values[k])
+		data[k] = values[k]
+	}
+	if !a.checkSize(secret, data) {
+		return
+	}
+	if a.cfg.SealCert != "" {
+		a.sealSecret(namespace, secret, data)
+		return
+	}
+
+	consumers, _ := a.showConsumers(namespace, secret)
+	label := fmt.Sprintf("Save generated %s to secret '%s/%s' key(s) '%s'", opts.Kind, namespace, secret, strings.Join(keys, "', '"))
+	if !a.confirm(label, secret) {
+		return
+	}
+	if !a.save(namespace, secret, current, data, func(ctx context.Context) error {
+		return a.k8s.SaveSecretKeys(ctx, namespace, secret, values)
+	}) {
+		return
+	}
+
+	fmt.Printf("Secret '%s' in namespace '%s' updated successfully.\n", secret, namespace)
+	a.restartConsumers(namespace, consumers)
+}
+
+// rotateKey sets new value of the key keeping the current one in
+// previousKey, so workloads can roll over to the new value. New value
+// is generated with opts or asked for if opts is nil.
+func (a *app) rotateKey(namespace, secret, key, previousKey string, current *Secret, opts *GenerateOptions) {
+	a.checkUpdateAccess(namespace, secret, current)
+	if _, ok := current.Data[previousKey]; ok {
+		fmt.Printf("Warning: key '%s' already holds a previous value, it will be overwritten.\n", previousKey)
+		if !promptConfirm("Overwrite previous value") {
+			fmt.Println("Rotation cancelled")
+			return
+		}
+	}
+
+	var value []byte
+	if opts != nil {
+		values, err := Generate(key, *opts)
+		if err != nil {
+			fatalf("Error generating value: %v", err)
+		}
+		value = values[key]
+	} else {
+		value = []byte(promptSecret(fmt.Sprintf("New value of key '%s'", key)))
+	}
+
+	data := RotatedData(current.Data, key, previousKey, value)
+	mask := PreviewMask(a.cfg.Mask)
+	for _, k := range []string{key, previousKey} {
+		fmt.Printf("Key '%s':\n", k)
+		printDiff(mask, current.Data[k], data[k])
+	}
+	if !a.checkSize(secret, data) {
+		return
+	}
+	if a.cfg.SealCert != "" {
+		a.sealSecret(namespace, secret, data)
+		return
+	}
+
+	consumers, _ := a.showConsumers(namespace, secret)
+	if !a.confirm(fmt.Sprintf("Rotate key '%s' in secret '%s/%s'", key, namespace, secret), secret) {
+		return
+	}
+	rotatedAt := time.Now()
+	if !a.save(namespace, secret, current, data, func(ctx context.Context) error {
+		return a.k8s.RotateSecretKey(ctx, namespace, secret, key, previousKey, value, rotatedAt)
+	}, func(s *corev1.Secret) { MarkRotated(&s.ObjectMeta, rotatedAt) }) {
+		return
+	}
+
+	fmt.Printf("Key '%s' rotated in secret '%s' in namespace '%s', previous value is kept in '%s'.\n", key, secret, namespace, previousKey)
+	fmt.Printf("Run 'secctl rotate --finish %s/%s %s' when rollout is done.\n", namespace, secret, key)
+	a.restartConsumers(namespace, consumers)
+}
+
+// finishRotation removes the previous value kept by rotateKey.
+func (a *app) finishRotation(namespace, secret, key, previousKey string, current *Secret) {
+	a.checkUpdateAccess(namespace, secret, current)
+	if _, ok := current.Data[previousKey]; !ok {
+		fatalf("No previous value of key '%s' found in '%s'", key, previousKey)
+	}
+	data := maps.Clone(current.Data)
+	delete(data, previousKey)
+	if a.cfg.SealCert != "" {
+		a.sealSecret(namespace, secret, data)
+		return
+	}
+
+	consumers, known := a.showConsumers(namespace, secret)
+	if !a.checkRemovedKeys(consumers, known, previousKey) {
+		return
+	}
+	if !a.confirm(fmt.Sprintf("Remove previous value '%s' from secret '%s/%s'", previousKey, namespace, secret), secret) {
+		return
+	}
+	if !a.save(namespace, secret, current, data, func(ctx context.Context) error {
+		return a.k8s.DeleteSecretKey(ctx, namespace, secret, previousKey)
+	}) {
+		return
+	}
+
+	fmt.Printf("Rotation of key '%s' in secret '%s' in namespace '%s' finished.\n", key, secret, namespace)
+}
+
+func (a *app) renameKey(namespace, secret, key string, current *Secret) {
+	a.checkUpdateAccess(namespace, secret, current)
+	prompt := promptui.Prompt{
+		Label:   fmt.Sprintf("New name for key '%s'", key),
+		Default: key,
+		Stdin:   promptStdin,
+		Validate: func(input string) error {
+			if errs := validation.IsConfigMapKey(input); len(errs) > 0 {
+				return fmt.Errorf("invalid key: %s", errs[0])
+			}
+			if _, ok := current.Data[input]; ok {
+				return fmt.Errorf("key '%s' already exists", input)
+			}
+			return nil
+		},
+	}
+	newKey, err := prompt.Run()
+	if err != nil {
+		fmt.Println("Rename cancelled")
+		return
+	}
+
+	data := maps.Clone(current.Data)
+	data[newKey] = data[key]
+	delete(data, key)
+	if a.cfg.SealCert != "" {
+		a.sealSecret(namespace, secret, data)
+		return
+	}
+
+	consumers, known := a.showConsumers(namespace, secret)
+	if !a.checkRemovedKeys(consumers, known, key) {
+		return
+	}
+	if !a.confirm(fmt.Sprintf("Rename key '%s' to '%s' in secret '%s/%s'", key, newKey, namespace, secret), secret) {
+		return
+	}
+
+	if !a.save(namespace, secret, current, data, func(ctx context.Context) error {
+		return a.k8s.RenameSecretKey(ctx, namespace, secret, key, newKey)
+	}) {
+		return
+	}
+
+	fmt.Printf("Key '%s' renamed to '%s' in secret '%s' in namespace '%s'.\n", key, newKey, secret, namespace)
+	a.restartConsumers(namespace, consumers)
+}
+
+func (a *app) deleteKey(namespace, secret, key string, current *Secret) {
+	a.checkUpdateAccess(namespace, secret, current)
+	data := maps.Clone(current.Data)
+	delete(data, key)
+	if a.cfg.SealCert != "" {
+		a.sealSecret(namespace, secret, data)
+		return
+	}
+
+	consumers, known := a.showConsumers(namespace, secret)
+	if !a.checkRemovedKeys(consumers, known, key) {
+		return
+	}
+	if !a.confirm(fmt.Sprintf("Delete key '%s' from secret '%s/%s'", key, namespace, secret), secret) {
+		return
+	}
+
+	if !a.save(namespace, secret, current, data, func(ctx context.Context) error {
+		return a.k8s.DeleteSecretKey(ctx, namespace, secret, key)
+	}) {
+		return
+	}
+
+	fmt.Printf("Key '%s' deleted from secret '%s' in namespace '%s'.\n", key, secret, namespace)
+	a.restartConsumers(namespace, consumers)
+}
+
+// checkUpdateAccess exits if user is not allowed to update the secret
+// or the context is read-only, so user doesn't lose the edit on save.
+// Immutable secrets are checked for delete and create permissions
+// needed to recreate them. Sealing doesn't change the cluster, so
+// nothing is checked.
+func (a *app) checkUpdateAccess(namespace, secret string, current *Secret) {
+	if a.cfg.SealCert != "" {
+		return
+	}
+	if a.rules.ReadOnly {
+		fatalf("Context '%s' is read-only", a.k8s.Context())
+	}
+	checks := [][2]string{{"update", secret}}
+	if current != nil && current.Immutable {
+		checks = [][2]string{{"delete", secret}, {"create", ""}}
+	}
+	for _, check := range checks {
+		allowed, err := withTimeoutCtx(func(ctx context.Context) (bool, error) {
+			return a.k8s.CanI(ctx, namespace, check[0], check[1])
+		})
+		if err != nil {
+			fmt.Printf("Warning: unable to check %s permission: %v\n", check[0], err)
+			return
+		}
+		if !allowed {
+			fatalf("Not allowed to %s secret '%s' in namespace '%s'", check[0], secret, namespace)
+		}
+	}
+}
+
+// checkSize checks data of the secret or ConfigMap against the API
+// server size limit before saving, so oversized changes fail early with
+// the largest keys shown. It returns false if data is too large.
+func (a *app) checkSize(name string, data SecretData) bool {
+	kind := strings.ToLower(a.kind)
+	check := CheckSecretSize(data)
+	if check.Exceeded() {
+		fmt.Printf("Error: %s '%s' exceeds the size limit and would be rejected.\n%s\n", kind, name, check.Format())
+		return false
+	}
+	if check.NearLimit() {
+		fmt.Printf("Warning: %s '%s' is close to the size limit.\n%s\n", kind, name, check.Format())
+	}
+	return true
+}
+
+// sealSecret writes SealedSecret with data of the secret instead
+// of saving it, since Sealed Secrets controller reverts live changes.
+func (a *app) sealSecret(namespace, secret string, data SecretData) {
+	key, err := LoadSealingKey(a.cfg.SealCert)
+	if err != nil {
+		fatalf("Error loading sealing key: %v", err)
+	}
+	manifest, err := withTimeoutCtx(func(ctx context.Context) (*corev1.Secret, error) {
+		return a.k8s.GetSecretManifest(ctx, namespace, secret)
+	})
+	if err != nil {
+		fatalf("Error loading secret: %v", err)
+	}
+	manifest.Data = data
+	sealed, err := SealSecret(manifest, key, a.cfg.SealScope)
+	if err != nil {
+		fatalf("Error sealing secret '%s' in namespace '%s': %v", secret, namespace, err)
+	}
+
+	if a.cfg.SealOutput == "" {
+		fmt.Print(string(sealed))
+		return
+	}
+	if err := os.WriteFile(a.cfg.SealOutput, sealed, 0o644); err != nil {
+		fatalf("Error writing %s: %v", a.cfg.SealOutput, err)
+	}
+	fmt.Printf("SealedSecret '%s' for namespace '%s' written to %s\n", secret, namespace, a.cfg.SealOutput)
+}
+
+// save applies changes with update function. Immutable secrets can't be
+// updated, so they are recreated with data after typed confirmation.
+// It returns false if save was cancelled.
+func (a *app) save(namespace, secret string, current *Secret, data SecretData, update func(context.Context) error, edits ...func(*corev1.Secret)) bool {
+	if !current.Immutable {
+		var keys []string
+		for _, c := range DiffSecretData(current.Data, data) {
+			keys = append(keys, c.Key)
+		}
+		_, err := withTimeoutCtx(func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, update(ctx)
+		})
+		if err != nil {
+			fatalf("Error saving secret '%s' in namespace '%s': %v", secret, namespace, err)
+		}
+		a.recordEvent(namespace, secret, keys)
+		return true
+	}
+
+	fmt.Printf("Secret '%s' is immutable and can only be changed by deleting and recreating it.\n", secret)
+	return a.recreate(namespace, secret, current, data, edits...)
+}
+
+// recreate deletes the secret and creates it with new data after typed
+// confirmation. Edits are applied to the new secret.
+func (a *app) recreate(namespace, secret string, current *Secret, data SecretData, edits ...func(*corev1.Secret)) bool {
+	if !typedConfirm("Recreate secret", secret) {
+		fmt.Println("Save cancelled")
+		return false
+	}
+	backupDir, err := BackupDir()
+	if err != nil {
+		fatalf("Error locating backup directory: %v", err)
+	}
+	backup, err := withTimeoutCtx(func(ctx context.Context) (string, error) {
+		return a.k8s.RecreateSecret(ctx, namespace, secret, data, backupDir, edits...)
+	})
+	if backup != "" {
+		fmt.Printf("Backup of the original secret saved to %s\n", backup)
+	}
+	if err != nil {
+		fatalf("Error recreating secret '%s' in namespace '%s': %v", secret, namespace, err)
+	}
+	if a.cfg.RemoveBackup {
+		if err := os.Remove(backup); err != nil {
+			fmt.Printf("Warning: unable to remove backup: %v\n", err)
+		} else {
+			fmt.Printf("Backup %s removed\n", backup)
+		}
+	}
+	var keys []string
+	for _, c := range DiffSecretData(current.Data, data) {
+		keys = append(keys, c.Key)
+	}
+	a.recordEvent(namespace, secret, keys)
+	return true
+}
+
+// recordEvent records change of secret keys as a cluster Event.
+// Failure doesn't affect the saved change, so it's only reported.
+func (a *app) recordEvent(namespace, secret string, keys []string) {
+	_, err := withTimeoutCtx(func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, a.k8s.RecordSecretEdited(ctx, namespace, secret, keys)
+	})
+	if err != nil {
+		fmt.Printf("Warning: unable to record event: %v\n", err)
+	}
+}
+
+// checkRemovedKeys reports workloads which require removed keys and
+// returns true if the change may proceed: either nothing breaks,
+// or user explicitly overrides the check. Removing keys while
+// consumers are unknown requires override as well.
+func (a *app) checkRemovedKeys(consumers []Consumer, known bool, keys ...string) bool {
+	if len(keys) == 0 {
+		return true
+	}
+	if known {
+		broken := FindBrokenRefs(consumers, keys...)
+		if len(broken) == 0 {
+			return true
+		}
+		fmt.Println("The following workloads will fail to start after the change:")
+		for _, b := range broken {
+			fmt.Printf("  - %s\n", b)
+		}
+	} else {
+		fmt.Println("Workloads using the secret are unknown, they may fail to start without removed keys.")
+	}
+	if a.cfg.Force {
+		fmt.Println("Proceeding because of --force.")
+		return true
+	}
+	if !typedConfirm("Save anyway", "override") {
+		fmt.Println("Save cancelled")
+		return false
+	}
+	return true
+}
+
+// applySecret replaces data, labels, annotations and type of the secret
+// with ones from manifest after showing changes and confirmation.
+// Missing secret is created.
+func (a *app) applySecret(manifest *corev1.Secret) {
+	namespace, name := manifest.Namespace, manifest.Name
+	live, err := withTimeoutCtx(func(ctx context.Context) (*corev1.Secret, error) {
+		return a.k8s.GetSecretManifest(ctx, namespace, name)
+	})
+	if apierrors.IsNotFound(err) {
+		a.createSecret(manifest)
+		return
+	}
+	if err != nil {
+		fatalf("Error loading secret: %v", err)
+	}
+	current := newSecret(live)
+	a.checkUpdateAccess(namespace, name, current)
+
+	r := PlanRestore(manifest, live)
+	secretType := manifest.Type
+	if secretType == "" {
+		secretType = corev1.SecretTypeOpaque
+	}
+	typeChanged := secretType != live.Type
+	if !r.Changed() && !typeChanged {
+		fmt.Println("No changes detected, exiting.")
+		return
+	}
+	a.printRestore(r)
+	if typeChanged {
+		fmt.Printf("  type changed from '%s' to '%s'\n", live.Type, secretType)
+	}
+	if !a.checkSize(name, manifest.Data) {
+		return
+	}
+	consumers, ok := a.checkRestoreConsumers(r)
+	if !ok || !a.confirm(fmt.Sprintf("Apply changes to secret '%s/%s'", namespace, name), name) {
+		return
+	}
+
+	edit := func(s *corev1.Secret) {
+		RestoreMetadata(s, manifest)
+		s.Type = secretType
+	}
+	var saved bool
+	if typeChanged {
+		fmt.Printf("Type of secret '%s' can only be changed by deleting and recreating it.\n", name)
+		saved = a.recreate(namespace, name, current, manifest.Data, edit)
+	} else {
+		saved = a.save(namespace, name, current, manifest.Data, func(ctx context.Context) error {
+			return a.k8s.RestoreSecret(ctx, manifest)
+		}, edit)
+	}
+	if !saved {
+		return
+	}
+
+	fmt.Printf("Secret '%s' in namespace '%s' updated successfully.\n", name, namespace)
+	a.restartConsumers(namespace, consumers)
+}
+
+// checkCreateAccess exits if user is not allowed to create the secret
+// or the context is read-only.
+func (a *app) checkCreateAccess(namespace, secret string) {
+	if a.rules.ReadOnly {
+		fatalf("Context '%s' is read-only", a.k8s.Context())
+	}
+	allowed, err := withTimeoutCtx(func(ctx context.Context) (bool, error) {
+		return a.k8s.CanI(ctx, namespace, "create", "")
+	})
+	if err != nil {
+		fmt.Printf("Warning: unable to check create permission: %v\n", err)
+	} else if !allowed {
+		fatalf("Not allowed to create secret '%s' in namespace '%s'", secret, namespace)
+	}
+}
+
+func (a *app) createSecret(manifest *corev1.Secret) {
+	namespace, name := manifest.Namespace, manifest.Name
+	a.checkCreateAccess(namespace, name)
+
+	for _, c := range DiffSecretData(nil, manifest.Data) {
+		fmt.Println(c.Format(a.cfg.Mask))
+	}
+	if !a.checkSize(name, manifest.Data) {
+		return
+	}
+	if !a.confirm(fmt.Sprintf("Create secret '%s/%s'", namespace, name), name) {
+		return
+	}
+	_, err := withTimeoutCtx(func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, a.k8s.CreateSecret(ctx, manifest)
+	})
+	if err != nil {
+		fatalf("Error creating secret '%s' in namespace '%s': %v", name, namespace, err)
+	}
+	a.recordEvent(namespace, name, slices.Collect(maps.Keys(manifest.Data)))
+	fmt.Printf("Secret '%s' in namespace '%s' created successfully.\n", name, namespace)
+}
+
+// restoreSecrets restores secrets from backup. It lists what would
+// change and asks to confirm each changed secret, unless all is set,
+// then a single confirmation restores all of them.
+func (a *app) restoreSecrets(backup []corev1.Secret, all bool) {
+	var restores []SecretRestore
+	for i := range backup {
+		manifest := &backup[i]
+		checkHidden(a.cfg, a.k8s, manifest.Namespace)
+		live, err := withTimeoutCtx(func(ctx context.Context) (*corev1.Secret, error) {
+			return a.k8s.GetSecretManifest(ctx, manifest.Namespace, manifest.Name)
+		})
+		if apierrors.IsNotFound(err) {
+			live = nil
+		} else if err != nil {
+			fatalf("Error loading secret: %v", err)
+		}
+		r := PlanRestore(manifest, live)
+		fmt.Println(r.Summary())
+		if r.Changed() {
+			restores = append(restores, r)
+		}
+	}
+	if len(restores) == 0 {
+		fmt.Println("No changes detected, exiting.")
+		return
+	}
+
+	var restored int
+	if all {
+		restored = a.restoreAll(restores)
+	} else {
+		restored = a.restoreEach(restores)
+	}
+	fmt.Printf("Restored %d of %d changed secret(s).\n", restored, len(restores))
+}
+
+// restoreAll restores secrets after one confirmation. Secrets which
+// would break workloads are skipped unless overridden.
+func (a *app) restoreAll(restores []SecretRestore) int {
+	var accepted []SecretRestore
+	var consumers [][]Consumer
+	for _, r := range restores {
+		fmt.Printf("\nSecret '%s':\n", r.Backup.Name)
+		c, ok := a.checkRestoreConsumers(r)
+		if ok {
+			accepted = append(accepted, r)
+			consumers = append(consumers, c)
+		}
+	}
+	if len(accepted) == 0 {
+		return 0
+	}
+	namespace := accepted[0].Backup.Namespace
+	if !a.confirm(fmt.Sprintf("Restore %d secret(s) in namespace '%s'", len(accepted), namespace), namespace) {
+		return 0
+	}
+	var restored int
+	for i, r := range accepted {
+		if a.restoreSecret(r) {
+			restored++
+			a.restartConsumers(namespace, consumers[i])
+		}
+	}
+	return restored
+}
+
+// restoreEach shows changes of every secret and restores confirmed ones.
+func (a *app) restoreEach(restores []SecretRestore) int {
+	var restored int
+	for _, r := range restores {
+		fmt.Printf("\nSecret '%s':\n", r.Backup.Name)
+		a.printRestore(r)
+		consumers, ok := a.checkRestoreConsumers(r)
+		if !ok || !a.confirm(fmt.Sprintf("Restore secret '%s'", r.Backup.Name), r.Backup.Name) {
+			continue
+		}
+		if a.restoreSecret(r) {
+			restored++
+			a.restartConsumers(r.Backup.Namespace, consumers)
+		}
+	}
+	return restored
+}
+
+// printRestore shows changes of the secret.
+func (a *app) printRestore(r SecretRestore) {
+	for _, c := range r.Changes {
+		fmt.Println(c.Format(a.cfg.Mask))
+	}
+	if r.LabelsChanged {
+		fmt.Println("  labels changed")
+	}
+	if r.AnnotationsChanged {
+		fmt.Println("  annotations changed")
+	}
+}
+
+// checkRestoreConsumers shows workloads using the live secret and checks
+// keys missing in backup. New secrets have no consumers to break.
+func (a *app) checkRestoreConsumers(r SecretRestore) ([]Consumer, bool) {
+	if r.Live == nil {
+		return nil, true
+	}
+	var removed []string
+	for _, c := range r.Changes {
+		if c.Kind == KeyRemoved {
+			removed = append(removed, c.Key)
+		}
+	}
+	consumers, known := a.showConsumers(r.Backup.Namespace, r.Backup.Name)
+	return consumers, a.checkRemovedKeys(consumers, known, removed...)
+}
+
+// restoreSecret creates or updates the secret from backup. Secret type
+// can't be changed, so such secrets are skipped.
+func (a *app) restoreSecret(r SecretRestore) bool {
+	namespace, name := r.Backup.Namespace, r.Backup.Name
+	if !a.checkSize(name, r.Backup.Data) {
+		return false
+	}
+	if r.Live == nil {
+		a.checkCreateAccess(namespace, name)
+		_, err := withTimeoutCtx(func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, a.k8s.CreateSecret(ctx, r.Backup.DeepCopy())
+		})
+		if err != nil {
+			fatalf("Error creating secret '%s' in namespace '%s': %v", name, namespace, err)
+		}
+		a.recordEvent(namespace, name, slices.Collect(maps.Keys(r.Backup.Data)))
+		fmt.Printf("Secret '%s' in namespace '%s' created successfully.\n", name, namespace)
+		return true
+	}
+	if r.Live.Type != r.Backup.Type {
+		fmt.Printf("Warning: skipping secret '%s', type changed from '%s' to '%s'\n", name, r.Backup.Type, r.Live.Type)
+		return false
+	}
+
+	current := newSecret(r.Live)
+	a.checkUpdateAccess(namespace, name, current)
+	if !a.save(namespace, name, current, r.Backup.Data, func(ctx context.Context) error {
+		return a.k8s.RestoreSecret(ctx, r.Backup)
+	}, func(s *corev1.Secret) { RestoreMetadata(s, r.Backup) }) {
+		return false
+	}
+	fmt.Printf("Secret '%s' in namespace '%s' restored successfully.\n", name, namespace)
+	return true
+}
+
+// viewHelmRelease prints decoded Helm release stored in the secret.
+func (a *app) viewHelmRelease(secret string, current *Secret) {
+	data, ok := current.Data[helmReleaseKey]
+	if !ok {
+		fatalf("Key '%s' not found in Helm release secret '%s'", helmReleaseKey, secret)
+	}
+	release, err := DecodeHelmRelease(data)
+	if err != nil {
+		fatalf("Error decoding Helm release secret '%s': %v", secret, err)
+	}
+	out, err := release.Format(PreviewMask(a.cfg.Mask))
+	if err != nil {
+		fatalf("Error rendering Helm release secret '%s': %v", secret, err)
+	}
+	fmt.Print(out)
+}
+
+func (a *app) editMetadata(namespace, secret string) {
+	a.checkUpdateAccess(namespace, secret, nil)
+	meta, err := withTimeoutCtx(func(ctx context.Context) (SecretMetadata, error) {
+		return a.k8s.GetSecretMetadata(ctx, namespace, secret)
+	})
+	if err != nil {
+		fatalf("Error loading secret metadata: %v", err)
+	}
+	originData, err := meta.MarshalYAML()
+	if err != nil {
+		fatalf("Error encoding secret metadata: %v", err)
+	}
+
+	edited, editedData, ok := a.editMetadataYAML(secret, originData)
+	if !ok {
+		return
+	}
+	printDiff(MaskNone, originData, editedData)
+	if !a.confirm(fmt.Sprintf("Apply metadata changes to secret '%s/%s'", namespace, secret), secret) {
+		return
+	}
+
+	_, err = withTimeoutCtx(func(ctx context.Context) (struct{}, error) {
+		err := a.k8s.SaveSecretMetadata(ctx, namespace, secret, edited)
+		return struct{}{}, err
+	})
+	if err != nil {
+		fatalf("Error saving secret '%s' in namespace '%s': %v", secret, namespace, err)
+	}
+
+	a.recordEvent(namespace, secret, nil)
+	fmt.Printf("Metadata of secret '%s' in namespace '%s' updated successfully.\n", secret, namespace)
+}
+
+// editMetadataYAML opens metadata in the editor until it's valid, so
+// a typo doesn't lose the edit. It returns false if user gives up or
+// nothing was changed compared to origin data. Invalid metadata left
+// unchanged on edit again is reported again.
+func (a *app) editMetadataYAML(secret string, originData []byte) (SecretMetadata, []byte, bool) {
+	data := originData
+	for {
+		editedData := editFile(a.editor, secret+"-metadata.yaml", data)
+		if slices.Equal(originData, editedData) {
+			fmt.Println("No changes detected, exiting.")
+			return SecretMetadata{}, nil, false
+		}
+		edited, err := ParseSecretMetadata(editedData)
+		if err == nil {
+			return edited, editedData, true
+		}
+		fmt.Printf("Error in edited metadata: %v\n", err)
+		if !promptConfirm("Edit again") {
+			fmt.Println("Edit cancelled")
+			return SecretMetadata{}, nil, false
+		}
+		data = editedData
+	}
+}
+
+// showConsumers prints workloads referencing the secret. It prints
+// a warning and returns false if workloads can't be listed, so
+// consumers are unknown.
+func (a *app) showConsumers(namespace, secret string) ([]Consumer, bool) {
+	consumers, err := withTimeoutCtx(func(ctx context.Context) ([]Consumer, error) {
+		return a.k8s.FindSecretConsumers(ctx, namespace, secret)
+	})
+	if err != nil {
+		fmt.Printf("Warning: unable to find secret consumers: %v\n", err)
+		return nil, false
+	}
+	fmt.Println(FormatConsumers(consumers))
+	return consumers, true
+}
+
+// listConsumers prints workloads referencing the secret and exits with
+// error if they can't be listed.
+func (a *app) listConsumers(namespace, secret string) {
+	if _, known := a.showConsumers(namespace, secret); !known {
+		os.Exit(1)
+	}
+}
+
+// restartConsumers offers to restart workloads referencing the secret
+// and optionally waits for their rollout.
+func (a *app) restartConsumers(namespace string, consumers []Consumer) {
+	restartable := RestartableConsumers(consumers)
+	if len(restartable) == 0 {
+		return
+	}
+	if !a.cfg.Restart && !promptConfirm(fmt.Sprintf("Restart %d workload(s) using this secret", len(restartable))) {
+		return
+	}
+
+	now := time.Now()
+	restarted := make([]Consumer, 0, len(restartable))
+	for _, c := range restartable {
+		_, err := withTimeoutCtx(func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, a.k8s.RestartWorkload(ctx, namespace, c, now)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error restarting %s/%s: %v\n", c.Kind, c.Name, err)
+			continue
+		}
+		fmt.Printf("%s/%s restarted\n", c.Kind, c.Name)
+		restarted = append(restarted, c)
+	}
+
+	if len(restarted) == 0 {
+		return
+	}
+	if !a.cfg.WaitRollout && (a.cfg.Restart || !promptConfirm("Wait for rollout to complete")) {
+		return
+	}
+	for _, c := range restarted {
+		msg, err := withSpinnerCtx(a.cfg.RolloutTimeout, func(ctx context.Context) (string, error) {
+			return a.k8s.WaitRollout(ctx, namespace, c)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s/%s rollout failed: %v\n", c.Kind, c.Name, err)
+			continue
+		}
+		fmt.Printf("%s/%s %s\n", c.Kind, c.Name, msg)
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Kinds of edited resources.
+const (
+	kindSecret    = "Secret"
+	kindConfigMap = "ConfigMap"
+)
+
+var resourceKinds = []string{kindSecret, kindConfigMap}
+
+// app holds dependencies of interactive workflows.
+type app struct {
+	cfg    *Config
+	k8s    *K8SClient
+	editor *Editor
+	// rules of the current kubeconfig context
+	rules  ContextRules
+	recent *Recent
+	// kind of the edited resource
+	kind string
+}
+
+func newApp(cfg *Config) *app {
+	a := newClientApp(cfg)
+	editor, err := NewEditor(cfg.EditorPath)
+	if err != nil {
+		fatalf("Error initializing editor: %v", err)
+	}
+	a.editor = editor
+	return a
+}
+
+// newClientApp creates app for workflows which don't open the editor.
+func newClientApp(cfg *Config) *app {
+	k8sClient, err := NewK8SClient(cfg.KubeConfig)
+	if err != nil {
+		fatalf("Error creating Kubernetes client: %v", err)
+	}
+	if cfg.SealCert != "" {
+		// fail before editing rather than losing the edit
+		if _, err := LoadSealingKey(cfg.SealCert); err != nil {
+			fatalf("Error loading sealing key: %v", err)
+		}
+	}
+
+	recentPath, err := RecentPath()
+	if err != nil {
+		fatalf("Error locating state directory: %v", err)
+	}
+	recent, err := LoadRecent(recentPath)
+	if err != nil {
+		fmt.Printf("Warning: unable to load recent items: %v\n", err)
+		recent = &Recent{path: recentPath, Contexts: make(map[string][]RecentItem)}
+	}
+
+	k8sClient.SetReason(cfg.Reason)
+
+	return &app{
+		cfg:    cfg,
+		k8s:    k8sClient,
+		rules:  cfg.ContextRules(k8sClient.Context()),
+		recent: recent,
+		kind:   kindSecret,
+	}
+}
+
+// run selects a key of the secret and performs an action on it.
+func (a *app) run(namespace, name string) {
+	a.remember(RecentItem{Namespace: namespace, Secret: name})
+	if a.cfg.ShowConsumers {
+		a.listConsumers(namespace, name)
+		return
+	}
+	if a.cfg.EditMetadata {
+		a.editMetadata(namespace, name)
+		return
+	}
+
+	secret, err := withTimeoutCtx(func(ctx context.Context) (*Secret, error) {
+		return a.k8s.GetSecret(ctx, namespace, name)
+	})
+	if err != nil {
+		fatalf("Error loading secret: %v", err)
+	}
+	if secret.Type == helmReleaseSecretType && a.cfg.Key == "" {
+		title := fmt.Sprintf("Secret '%s' is a Helm release", name)
+		if selectAction(title, helmReleaseActions) == actionViewRelease {
+			a.viewHelmRelease(name, secret)
+			return
+		}
+	}
+	keys := make([]string, 0, len(secret.Data))
+	for k := range secret.Data {
+		keys = append(keys, k)
+	}
+	key := a.cfg.Key
+	if key == "" {
+		label := fmt.Sprintf("Select key in secret '%s'", name)
+		if secret.Immutable {
+			label += " (immutable)"
+		}
+		details := func(key string) string {
+			return FormatKeyDetails(secret.Data[key], a.cfg.Mask)
+		}
+		key = runDetailsPrompt(label, keys, details, a.recent.Keys(a.k8s.Context(), namespace, name)...)
+	}
+	if _, ok := secret.Data[key]; !ok {
+		fatalf("Key '%s' not found in secret '%s' in namespace '%s'", key, name, namespace)
+	}
+	a.remember(RecentItem{Namespace: namespace, Secret: name, Key: key})
+
+	switch selectAction(fmt.Sprintf("Action for key '%s'", key), keyActions) {
+	case actionEdit:
+		a.editKey(namespace, name, key, secret)
+	case actionGenerate:
+		a.generateKey(namespace, name, key, secret, a.promptGenerateOptions(name))
+	case actionRename:
+		a.renameKey(namespace, name, key, secret)
+	case actionDelete:
+		a.deleteKey(namespace, name, key, secret)
+	}
+}
+
+// remember adds item to recent items of the current context.
+func (a *app) remember(item RecentItem) {
+	item.UsedAt = time.Now()
+	a.recent.Add(a.k8s.Context(), item)
+	if err := a.recent.Save(); err != nil {
+		fmt.Printf("Warning: unable to save recent items: %v\n", err)
+	}
+}
+
+// selectSecret returns namespace and name of the secret from flags
+// or asks user to select them.
+func (a *app) selectSecret() (string, string) {
+	if namespace, name, ok := strings.Cut(a.cfg.Secret, "/"); ok {
+		checkHidden(a.cfg, a.k8s, namespace)
+		return namespace, name
+	}
+
+	if a.cfg.AllNamespaces && a.cfg.Namespace == "" {
+		secrets, err := withTimeoutCtx(func(ctx context.Context) ([]string, error) {
+			return a.k8s.ListAllSecrets(ctx, a.cfg.Filter)
+		})
+		if err != nil {
+			fatalf("Error loading secrets: %v", err)
+		}
+		secrets = slices.DeleteFunc(secrets, func(item string) bool {
+			namespace, _, _ := strings.Cut(item, "/")
+			return a.rules.IsHidden(namespace)
+		})
+		if len(secrets) == 0 {
+			fatalf("No secrets found (%s)", a.cfg.Filter)
+		}
+		pinned := append(slices.Clone(a.cfg.FavoriteSecrets), a.recent.Secrets(a.k8s.Context())...)
+		selected := runDetailsPrompt("Select secret", secrets, a.secretDetails(""), pinned...)
+		namespace, name, _ := strings.Cut(selected, "/")
+		return namespace, name
+	}
+
+	namespace := a.selectNamespace()
+	if a.cfg.Secret != "" {
+		return namespace, a.cfg.Secret
+	}
+	secrets, err := withTimeoutCtx(func(ctx context.Context) ([]string, error) {
+		return a.k8s.ListSecrets(ctx, namespace, a.cfg.Filter)
+	})
+	if err != nil {
+		fatalf("Error loading secrets: %v", err)
+	}
+	if len(secrets) == 0 {
+		fatalf("No secrets found in namespace '%s' (%s)", namespace, a.cfg.Filter)
+	}
+	var pinned []string
+	for _, s := range a.cfg.FavoriteSecrets {
+		if ns, name, ok := strings.Cut(s, "/"); ok && ns == namespace {
+			pinned = append(pinned, name)
+		}
+	}
+	pinned = append(pinned, a.recent.SecretsIn(a.k8s.Context(), namespace)...)
+	return namespace, runDetailsPrompt(fmt.Sprintf("Select secret in '%s'", namespace), secrets,
+		a.secretDetails(namespace), pinned...)
+}
+
+// selectKind returns kind of the edited resource from flags or asks
+// user to select one. Secret-only flags select secrets.
+func (a *app) selectKind() string {
+	switch {
+	case a.cfg.ConfigMap:
+		a.kind = kindConfigMap
+	case a.cfg.Secret != "" || a.cfg.AllNamespaces || a.cfg.EditMetadata ||
+		a.cfg.ShowConsumers || a.cfg.SealCert != "":
+		a.kind = kindSecret
+	default:
+		a.kind = selectAction("Select resource kind", resourceKinds)
+	}
+	return a.kind
+}
+
+// selectConfigMap returns namespace and name of the ConfigMap
+// selected by user.
+func (a *app) selectConfigMap() (string, string) {
+	namespace := a.selectNamespace()
+	configMaps, err := withTimeoutCtx(func(ctx context.Context) ([]string, error) {
+		return a.k8s.ListConfigMaps(ctx, namespace)
+	})
+	if err != nil {
+		fatalf("Error loading configmaps: %v", err)
+	}
+	if len(configMaps) == 0 {
+		fatalf("No configmaps found in namespace '%s'", namespace)
+	}
+	return namespace, runPrompt(fmt.Sprintf("Select configmap in '%s'", namespace), configMaps)
+}
+
+// runConfigMap selects a key of the ConfigMap and edits it.
+func (a *app) runConfigMap(namespace, name string) {
+	cm, err := withTimeoutCtx(func(ctx context.Context) (*ConfigMap, error) {
+		return a.k8s.GetConfigMap(ctx, namespace, name)
+	})
+	if err != nil {
+		fatalf("Error loading configmap: %v", err)
+	}
+	values := cm.Values()
+	key := a.cfg.Key
+	if key == "" {
+		if len(values) == 0 {
+			fatalf("ConfigMap '%s' in namespace '%s' has no keys", name, namespace)
+		}
+		keys := slices.Collect(maps.Keys(values))
+		label := fmt.Sprintf("Select key in configmap '%s'", name)
+		if cm.Immutable {
+			label += " (immutable)"
+		}
+		details := func(key string) string {
+			return FormatKeyDetails(values[key], MaskNone)
+		}
+		key = runDetailsPrompt(label, keys, details)
+	}
+	if _, ok := values[key]; !ok {
+		fatalf("Key '%s' not found in configmap '%s' in namespace '%s'", key, name, namespace)
+	}
+	a.editConfigMapKey(namespace, name, key, cm)
+}
+
+// selectNamespace returns namespace from flags or asks user to select one.
+// If user is not allowed to list namespaces, it falls back to the namespace
+// of the current kubeconfig context. Namespaces are filtered by access
+// to the selected resource kind.
+func (a *app) selectNamespace() string {
+	if a.cfg.Namespace != "" {
+		checkHidden(a.cfg, a.k8s, a.cfg.Namespace)
+		return a.cfg.Namespace
+	}
+
+	namespaces, err := withTimeoutCtx(func(ctx context.Context) ([]string, error) {
+		return a.k8s.ListNamespaces(ctx)
+	})
+	if apierrors.IsForbidden(err) {
+		fmt.Printf("Not allowed to list namespaces, using namespace '%s' from kubeconfig context.\n",
+			a.k8s.Namespace())
+		checkHidden(a.cfg, a.k8s, a.k8s.Namespace())
+		return a.k8s.Namespace()
+	}
+	if err != nil {
+		fatalf("Error loading namespaces: %v", err)
+	}
+	namespaces = slices.DeleteFunc(namespaces, a.rules.IsHidden)
+
+	filter, resource := a.k8s.FilterSecretNamespaces, "secrets"
+	if a.kind == kindConfigMap {
+		filter, resource = a.k8s.FilterConfigMapNamespaces, "configmaps"
+	}
+	allowed, err := withTimeoutCtx(func(ctx context.Context) ([]string, error) {
+		return filter(ctx, namespaces)
+	})
+	switch {
+	case err != nil:
+		fmt.Printf("Warning: unable to check access to %s: %v\n", resource, err)
+	case len(allowed) == 0:
+		fatalf("Not allowed to list %s in any namespace", resource)
+	default:
+		namespaces = allowed
+	}
+	pinned := append([]string{a.k8s.Namespace()}, a.cfg.FavoriteNamespaces...)
+	pinned = append(pinned, a.recent.Namespaces(a.k8s.Context())...)
+	return runPrompt("Select namespace", namespaces, pinned...)
+}
+
+// checkHidden exits if namespace is hidden by rules of the current
+// kubeconfig context, so it can't be used from flags either.
+func checkHidden(cfg *Config, k8sClient *K8SClient, namespace string) {
+	if cfg.ContextRules(k8sClient.Context()).IsHidden(namespace) {
+		fatalf("Namespace '%s' is hidden in context '%s'", namespace, k8sClient.Context())
+	}
+}
+
+// selectRecent asks user to select one of recently used secrets.
+func (a *app) selectRecent() (string, string) {
+	items := a.recent.Secrets(a.k8s.Context())
+	for _, s := range a.cfg.FavoriteSecrets {
+		if !slices.Contains(items, s) {
+			items = append(items, s)
+		}
+	}
+	items = slices.DeleteFunc(items, func(item string) bool {
+		namespace, _, _ := strings.Cut(item, "/")
+		return a.rules.IsHidden(namespace)
+	})
+	if len(items) == 0 {
+		fatalf("No recent secrets in context '%s'", a.k8s.Context())
+	}
+	selected := runDetailsPrompt("Select recent secret", items, a.secretDetails(""), items...)
+	namespace, name, _ := strings.Cut(selected, "/")
+	return namespace, name
+}
+
+// secretDetails returns details function for secret prompt items.
+// Items are secret names in namespace, or "namespace/name" if namespace
+// is empty. Secrets are loaded only when the cursor stays on them.
+func (a *app) secretDetails(namespace string) func(string) string {
+	return asyncDetails(detailsDelay, func(ctx context.Context, item string) string {
+		ns, name := namespace, item
+		if ns == "" {
+			ns, name, _ = strings.Cut(item, "/")
+		}
+		summary, err := a.k8s.GetSecretSummary(ctx, ns, name)
+		if err != nil {
+			return fmt.Sprintf("Details unavailable: %v", err)
+		}
+		return summary.Format(time.Now())
+	}, promptStdin.Refresh)
+}
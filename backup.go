@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+
+	"filippo.io/age"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// ListSecretManifests returns manifests of all secrets in namespace.
+func (k *K8SClient) ListSecretManifests(ctx context.Context, namespace string) ([]corev1.Secret, error) {
+	var res []corev1.Secret
+	opts := metav1.ListOptions{Limit: listPageSize}
+	for {
+		page, err := k.clientset.CoreV1().Secrets(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("list secrets in namespace '%s': %w", namespace, err)
+		}
+		for i := range page.Items {
+			res = append(res, *SecretManifest(&page.Items[i]))
+		}
+		if page.Continue == "" {
+			return res, nil
+		}
+		opts.Continue = page.Continue
+	}
+}
+
+// RestoreMetadata replaces labels and annotations of the secret with
+// ones from manifest. Annotations dropped from manifests are kept.
+func RestoreMetadata(secret, manifest *corev1.Secret) {
+	annotations := maps.Clone(manifest.Annotations)
+	for _, k := range droppedManifestAnnotations {
+		if v, ok := secret.Annotations[k]; ok {
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			annotations[k] = v
+		}
+	}
+	secret.Labels = maps.Clone(manifest.Labels)
+	secret.Annotations = annotations
+}
+
+// RestoreSecret replaces data, labels and annotations of the secret
+// with ones from manifest.
+func (k *K8SClient) RestoreSecret(ctx context.Context, manifest *corev1.Secret) error {
+	namespace, name := manifest.Namespace, manifest.Name
+	secret, err := k.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	for _, c := range DiffSecretData(secret.Data, manifest.Data) {
+		keys = append(keys, c.Key)
+	}
+	secret.Data = manifest.Data
+	RestoreMetadata(secret, manifest)
+	k.stamp(ctx, &secret.ObjectMeta, keys...)
+
+	if _, err = k.clientset.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	return nil
+}
+
+// EncryptBackup encodes secrets as a SecretList manifest encrypted
+// for age recipients. Decrypted archive can be applied with kubectl.
+func EncryptBackup(secrets []corev1.Secret, recipients []*age.X25519Recipient) ([]byte, error) {
+	list := corev1.SecretList{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "SecretList"},
+		Items:    secrets,
+	}
+	manifest, err := yaml.Marshal(list)
+	if err != nil {
+		return nil, fmt.Errorf("marshal backup: %w", err)
+	}
+
+	ageRecipients := make([]age.Recipient, len(recipients))
+	for i, r := range recipients {
+		ageRecipients[i] = r
+	}
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, ageRecipients...)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt backup: %w", err)
+	}
+	if _, err := w.Write(manifest); err != nil {
+		return nil, fmt.Errorf("encrypt backup: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("encrypt backup: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecryptBackup decrypts backup archive with age identities.
+func DecryptBackup(data []byte, identities []age.Identity) ([]corev1.Secret, error) {
+	r, err := age.Decrypt(bytes.NewReader(data), identities...)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt backup: %w", err)
+	}
+	manifest, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt backup: %w", err)
+	}
+	var list corev1.SecretList
+	if err := yaml.Unmarshal(manifest, &list); err != nil {
+		return nil, fmt.Errorf("parse backup: %w", err)
+	}
+	if list.Kind != "SecretList" {
+		return nil, fmt.Errorf("parse backup: expected SecretList, got '%s'", list.Kind)
+	}
+	return list.Items, nil
+}
+
+// SecretRestore compares secret from backup with the live one.
+type SecretRestore struct {
+	Backup *corev1.Secret
+	// Live secret, nil if it doesn't exist.
+	Live               *corev1.Secret
+	Changes            []KeyChange
+	LabelsChanged      bool
+	AnnotationsChanged bool
+}
+
+// PlanRestore compares backup of the secret with the live secret.
+func PlanRestore(backup, live *corev1.Secret) SecretRestore {
+	r := SecretRestore{Backup: backup, Live: live}
+	if live == nil {
+		r.Changes = DiffSecretData(nil, backup.Data)
+		return r
+	}
+	r.Changes = DiffSecretData(live.Data, backup.Data)
+	r.LabelsChanged = !maps.Equal(live.Labels, backup.Labels)
+	r.AnnotationsChanged = !maps.Equal(userAnnotations(live.Annotations), userAnnotations(backup.Annotations))
+	return r
+}
+
+// userAnnotations returns annotations without provenance ones, which
+// change with every write, and ones dropped from manifests.
+func userAnnotations(annotations map[string]string) map[string]string {
+	res := manifestAnnotations(annotations)
+	maps.DeleteFunc(res, func(k, _ string) bool {
+		return strings.HasPrefix(k, "secctl.io/last-modified-")
+	})
+	return res
+}
+
+// Changed checks if restore changes the cluster.
+func (r SecretRestore) Changed() bool {
+	return r.Live == nil || len(r.Changes) > 0 || r.LabelsChanged || r.AnnotationsChanged
+}
+
+// Summary describes restore of the secret in one line.
+func (r SecretRestore) Summary() string {
+	if r.Live == nil {
+		return fmt.Sprintf("%s: new (%d keys)", r.Backup.Name, len(r.Backup.Data))
+	}
+	if !r.Changed() {
+		return fmt.Sprintf("%s: unchanged", r.Backup.Name)
+	}
+	var parts []string
+	for _, c := range r.Changes {
+		parts = append(parts, c.Kind+c.Key)
+	}
+	if r.LabelsChanged {
+		parts = append(parts, "labels")
+	}
+	if r.AnnotationsChanged {
+		parts = append(parts, "annotations")
+	}
+	return fmt.Sprintf("%s: %s", r.Backup.Name, strings.Join(parts, " "))
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestListSecretManifests(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default", ResourceVersion: "1"},
+			Data:       map[string][]byte{"key": []byte("value")},
+		},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "default"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "other"}},
+	)
+	client := &K8SClient{clientset: fakeClientset}
+
+	secrets, err := client.ListSecretManifests(context.Background(), "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(secrets) != 2 {
+		t.Fatalf("expected 2 secrets, got %d", len(secrets))
+	}
+	for _, s := range secrets {
+		if s.Namespace != "default" || s.ResourceVersion != "" || s.Kind != "Secret" {
+			t.Errorf("unexpected manifest metadata: %+v", s.ObjectMeta)
+		}
+	}
+}
+
+func TestRestoreSecret(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "mysecret",
+				Namespace:   "default",
+				Labels:      map[string]string{"app": "new"},
+				Annotations: map[string]string{"note": "new", corev1.ServiceAccountUIDKey: "uid-1"},
+			},
+			Data: map[string][]byte{"key1": []byte("changed"), "key2": []byte("added")},
+		},
+	)
+	client := &K8SClient{clientset: fakeClientset}
+	ctx := context.Background()
+
+	backup := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "mysecret",
+			Namespace:   "default",
+			Labels:      map[string]string{"app": "old"},
+			Annotations: map[string]string{"note": "old"},
+		},
+		Data: map[string][]byte{"key1": []byte("value1")},
+	}
+	if err := client.RestoreSecret(ctx, backup); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secret, err := fakeClientset.CoreV1().Secrets("default").Get(ctx, "mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(secret.Data) != 1 || string(secret.Data["key1"]) != "value1" {
+		t.Errorf("expected only key1 with 'value1', got %v", secret.Data)
+	}
+	if secret.Labels["app"] != "old" || secret.Annotations["note"] != "old" {
+		t.Errorf("expected metadata from backup, got %+v", secret.ObjectMeta)
+	}
+	if secret.Annotations[corev1.ServiceAccountUIDKey] != "uid-1" {
+		t.Errorf("expected service account UID to be kept, got %v", secret.Annotations)
+	}
+	if keys := secret.Annotations[lastModifiedKeysAnnotation]; keys != "key1,key2" {
+		t.Errorf("expected changed keys 'key1,key2', got '%s'", keys)
+	}
+	if _, ok := backup.Annotations[lastModifiedKeysAnnotation]; ok {
+		t.Error("expected backup annotations to be left unchanged")
+	}
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate age identity: %v", err)
+	}
+	secrets := []corev1.Secret{
+		*SecretManifest(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{"password": []byte("secret123")},
+		}),
+	}
+
+	data, err := EncryptBackup(secrets, []*age.X25519Recipient{identity.Recipient()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bytes.Contains(data, []byte("secret123")) || bytes.Contains(data, []byte("c2VjcmV0MTIz")) {
+		t.Error("expected backup to be encrypted")
+	}
+
+	restored, err := DecryptBackup(data, []age.Identity{identity})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(restored) != 1 || restored[0].Name != "db" || string(restored[0].Data["password"]) != "secret123" {
+		t.Errorf("unexpected restored secrets: %+v", restored)
+	}
+
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate age identity: %v", err)
+	}
+	if _, err := DecryptBackup(data, []age.Identity{other}); err == nil {
+		t.Error("expected error for wrong identity, got nil")
+	}
+}
+
+func TestPlanRestore(t *testing.T) {
+	backup := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "db",
+			Annotations: map[string]string{lastModifiedAtAnnotation: "yesterday"},
+		},
+		Data: map[string][]byte{"a": []byte("1"), "b": []byte("2")},
+	}
+
+	tests := []struct {
+		name     string
+		live     *corev1.Secret
+		changed  bool
+		expected string
+	}{
+		{"missing", nil, true, "db: new (2 keys)"},
+		{"same", &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{lastModifiedAtAnnotation: "today"}},
+			Data:       map[string][]byte{"a": []byte("1"), "b": []byte("2")},
+		}, false, "db: unchanged"},
+		{"changed", &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "db"}},
+			Data:       map[string][]byte{"a": []byte("0"), "c": []byte("3")},
+		}, true, "db: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := PlanRestore(backup, tt.live)
+			if r.Changed() != tt.changed {
+				t.Errorf("expected changed %v, got %v", tt.changed, r.Changed())
+			}
+			if !strings.HasPrefix(r.Summary(), tt.expected) {
+				t.Errorf("expected summary '%s', got '%s'", tt.expected, r.Summary())
+			}
+		})
+	}
+
+	r := PlanRestore(backup, tests[2].live)
+	if len(r.Changes) != 3 || !r.LabelsChanged || r.AnnotationsChanged {
+		t.Errorf("unexpected restore plan: %+v", r)
+	}
+}
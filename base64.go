@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minBase64Length is the shortest value considered accidentally encoded,
+// short words like "test" or "admin" are often valid base64 too.
+const minBase64Length = 8
+
+// DecodeBase64Layer checks if value is a base64 encoded printable text,
+// which is usually a mistake, since secret data is already base64 encoded
+// by Kubernetes. It returns decoded value if so.
+func DecodeBase64Layer(data []byte) ([]byte, bool) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) < minBase64Length || len(trimmed)%4 != 0 {
+		return nil, false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(string(trimmed))
+	if err != nil || len(bytes.TrimSpace(decoded)) == 0 || !isPrintable(decoded) {
+		return nil, false
+	}
+	return decoded, true
+}
+
+// EncodeBase64Layer encodes value back, keeping trailing newline
+// of the original encoded value.
+func EncodeBase64Layer(decoded, original []byte) []byte {
+	encoded := []byte(base64.StdEncoding.EncodeToString(decoded))
+	if bytes.HasSuffix(original, []byte("\n")) {
+		encoded = append(encoded, '\n')
+	}
+	return encoded
+}
+
+func isPrintable(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
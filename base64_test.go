@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestDecodeBase64Layer(t *testing.T) {
+	tests := []struct {
+		data     string
+		decoded  string
+		expected bool
+	}{
+		{"c2VjcmV0MTIz", "secret123", true},
+		{"c2VjcmV0MTIz\n", "secret123", true},
+		{"cGFzc3dvcmQ=", "password", true},
+		{"test", "", false},
+		{"secret123", "", false},
+		{"plainpassword", "", false},
+		{"//////////8=", "", false},
+	}
+	for _, tt := range tests {
+		decoded, ok := DecodeBase64Layer([]byte(tt.data))
+		if ok != tt.expected {
+			t.Errorf("%q: expected %v, got %v", tt.data, tt.expected, ok)
+			continue
+		}
+		if string(decoded) != tt.decoded {
+			t.Errorf("%q: expected decoded %q, got %q", tt.data, tt.decoded, decoded)
+		}
+	}
+}
+
+func TestEncodeBase64Layer(t *testing.T) {
+	if res := EncodeBase64Layer([]byte("secret123"), []byte("b2xk")); string(res) != "c2VjcmV0MTIz" {
+		t.Errorf("expected 'c2VjcmV0MTIz', got '%s'", res)
+	}
+	if res := EncodeBase64Layer([]byte("secret123"), []byte("b2xk\n")); string(res) != "c2VjcmV0MTIz\n" {
+		t.Errorf("expected trailing newline to be kept, got %q", res)
+	}
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// runCommand runs a subcommand.
+func runCommand(cfg *Config) {
+	name, args := cfg.Command[0], cfg.Command[1:]
+	switch {
+	case name == "config" && len(args) == 1 && args[0] == "view":
+		runConfigView(cfg)
+	case name == "recent" && len(args) == 0:
+		a := newApp(cfg)
+		a.run(a.selectRecent())
+	case name == "watch" && len(args) == 1:
+		runWatch(cfg, args[0])
+	case name == "export":
+		runExport(cfg, args)
+	case name == "apply-sops" && len(args) == 1:
+		runApplySOPS(cfg, args[0])
+	case name == "generate":
+		runGenerate(cfg, args)
+	case name == "rotate":
+		runRotate(cfg, args)
+	case name == "backup":
+		runBackup(cfg, args)
+	case name == "restore":
+		runRestore(cfg, args)
+	default:
+		fatalf("Unknown command: %s", strings.Join(cfg.Command, " "))
+	}
+}
+
+func runConfigView(cfg *Config) {
+	data, err := yaml.Marshal(cfg.Effective())
+	if err != nil {
+		fatalf("Error encoding config: %v", err)
+	}
+	fmt.Printf("# %s\n%s", cfg.ConfigPath, data)
+}
+
+func runWatch(cfg *Config, ref string) {
+	k8sClient, err := NewK8SClient(cfg.KubeConfig)
+	if err != nil {
+		fatalf("Error creating Kubernetes client: %v", err)
+	}
+	namespace, name := parseSecretRef(cfg, k8sClient, ref)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Watching secret '%s' in namespace '%s', press Ctrl+C to stop.\n", name, namespace)
+	mask := PreviewMask(cfg.Mask)
+	err = k8sClient.WatchSecret(ctx, namespace, name, func(ev SecretEvent) {
+		fmt.Println(ev.Format(time.Now(), mask))
+	})
+	if err != nil {
+		fatalf("Error watching secret: %v", err)
+	}
+}
+
+// parseCommandFlags parses subcommand flags placed before, after or
+// between positional arguments and returns the positional arguments.
+func parseCommandFlags(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		_ = fs.Parse(args)
+		if fs.NArg() == 0 {
+			return positional
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// parseSecretRef parses "namespace/name" secret reference. Namespace of
+// a plain name defaults to the namespace flag or kubeconfig context.
+// Hidden namespaces are rejected.
+func parseSecretRef(cfg *Config, k8sClient *K8SClient, ref string) (string, string) {
+	namespace, name, ok := strings.Cut(ref, "/")
+	switch {
+	case !ok && cfg.Namespace != "":
+		namespace, name = cfg.Namespace, ref
+	case !ok:
+		namespace, name = k8sClient.Namespace(), ref
+	}
+	checkHidden(cfg, k8sClient, namespace)
+	return namespace, name
+}
+
+func runExport(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	sops := fs.Bool("sops", false, "Encrypt exported secret with SOPS")
+	recipients := fs.String("age", os.Getenv("SOPS_AGE_RECIPIENTS"), "Comma separated age recipients (default: $SOPS_AGE_RECIPIENTS)")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: secctl export --sops --age <recipients> [-o file] <namespace/secret>")
+		fs.PrintDefaults()
+	}
+	args = parseCommandFlags(fs, args)
+	if len(args) != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if !*sops {
+		fatalf("Only SOPS encrypted export is supported, use --sops")
+	}
+	ageRecipients, err := ParseAgeRecipients(*recipients)
+	if err != nil {
+		fatalf("Error parsing --age: %v", err)
+	}
+
+	k8sClient, err := NewK8SClient(cfg.KubeConfig)
+	if err != nil {
+		fatalf("Error creating Kubernetes client: %v", err)
+	}
+	namespace, name := parseSecretRef(cfg, k8sClient, args[0])
+	manifest, err := withTimeoutCtx(func(ctx context.Context) (*corev1.Secret, error) {
+		return k8sClient.GetSecretManifest(ctx, namespace, name)
+	})
+	if err != nil {
+		fatalf("Error loading secret: %v", err)
+	}
+	data, err := EncryptSOPS(manifest, ageRecipients, time.Now())
+	if err != nil {
+		fatalf("Error encrypting secret: %v", err)
+	}
+
+	if *output == "" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			fatalf("Error writing secret: %v", err)
+		}
+		return
+	}
+	if err := WritePrivateFile(*output, data); err != nil {
+		fatalf("Error writing %s: %v", *output, err)
+	}
+	fmt.Printf("Secret '%s' in namespace '%s' exported to %s\n", name, namespace, *output)
+}
+
+func runApplySOPS(cfg *Config, p string) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		fatalf("Error reading %s: %v", p, err)
+	}
+	identities, err := LoadAgeIdentities()
+	if err != nil {
+		fatalf("Error loading age identities: %v", err)
+	}
+	manifest, err := DecryptSOPS(data, identities)
+	if err != nil {
+		fatalf("Error decrypting %s: %v", p, err)
+	}
+
+	a := newClientApp(cfg)
+	if manifest.Namespace == "" {
+		manifest.Namespace, _ = parseSecretRef(cfg, a.k8s, manifest.Name)
+	}
+	checkHidden(cfg, a.k8s, manifest.Namespace)
+	a.remember(RecentItem{Namespace: manifest.Namespace, Secret: manifest.Name})
+	a.applySecret(manifest)
+}
+
+func runBackup(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	var namespace string
+	fs.StringVar(&namespace, "namespace", cfg.Namespace, "Namespace to back up (default: kubeconfig context namespace)")
+	fs.StringVar(&namespace, "n", cfg.Namespace, "Shorthand for -namespace")
+	recipients := fs.String("age", os.Getenv("SOPS_AGE_RECIPIENTS"), "Comma separated age recipients (default: $SOPS_AGE_RECIPIENTS)")
+	output := fs.String("o", "", "Output file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: secctl backup [-n namespace] --age <recipients> -o <file>")
+		fs.PrintDefaults()
+	}
+	if len(parseCommandFlags(fs, args)) != 0 || *output == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	ageRecipients, err := ParseAgeRecipients(*recipients)
+	if err != nil {
+		fatalf("Error parsing --age: %v", err)
+	}
+
+	k8sClient, err := NewK8SClient(cfg.KubeConfig)
+	if err != nil {
+		fatalf("Error creating Kubernetes client: %v", err)
+	}
+	if namespace == "" {
+		namespace = k8sClient.Namespace()
+	}
+	checkHidden(cfg, k8sClient, namespace)
+	secrets, err := withTimeoutCtx(func(ctx context.Context) ([]corev1.Secret, error) {
+		return k8sClient.ListSecretManifests(ctx, namespace)
+	})
+	if err != nil {
+		fatalf("Error loading secrets: %v", err)
+	}
+	data, err := EncryptBackup(secrets, ageRecipients)
+	if err != nil {
+		fatalf("Error encrypting backup: %v", err)
+	}
+	if err := WritePrivateFile(*output, data); err != nil {
+		fatalf("Error writing %s: %v", *output, err)
+	}
+	fmt.Printf("%d secret(s) in namespace '%s' backed up to %s\n", len(secrets), namespace, *output)
+}
+
+func runRestore(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	all := fs.Bool("all", false, "Restore all changed secrets after a single confirmation")
+	var namespace string
+	fs.StringVar(&namespace, "namespace", "", "Restore into this namespace instead of the backed up one")
+	fs.StringVar(&namespace, "n", "", "Shorthand for -namespace")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: secctl restore [--all] [-n namespace] <file> [secret...]")
+		fs.PrintDefaults()
+	}
+	args = parseCommandFlags(fs, args)
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	p, names := args[0], args[1:]
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		fatalf("Error reading %s: %v", p, err)
+	}
+	identities, err := LoadAgeIdentities()
+	if err != nil {
+		fatalf("Error loading age identities: %v", err)
+	}
+	secrets, err := DecryptBackup(data, identities)
+	if err != nil {
+		fatalf("Error decrypting %s: %v", p, err)
+	}
+
+	var selected []corev1.Secret
+	for _, s := range secrets {
+		if len(names) > 0 && !slices.Contains(names, s.Name) {
+			continue
+		}
+		if namespace != "" {
+			s.Namespace = namespace
+		}
+		selected = append(selected, s)
+	}
+	for _, name := range names {
+		if !slices.ContainsFunc(selected, func(s corev1.Secret) bool { return s.Name == name }) {
+			fatalf("Secret '%s' not found in %s", name, p)
+		}
+	}
+	if len(selected) == 0 {
+		fmt.Println("Backup is empty, exiting.")
+		return
+	}
+
+	a := newClientApp(cfg)
+	a.restoreSecrets(selected, *all)
+}
+
+// generateFlags are value generator options of subcommands.
+type generateFlags struct {
+	kind     *string
+	length   *int
+	charsets *string
+	bytes    *int
+	bits     *int
+	cn       *string
+	hosts    *string
+	validFor *time.Duration
+}
+
+func addGenerateFlags(fs *flag.FlagSet, kind GeneratorKind) *generateFlags {
+	return &generateFlags{
+		kind:     fs.String("kind", string(kind), "Kind of value: password, hex, base64, uuid, ssh-rsa, ssh-ed25519 or tls"),
+		length:   fs.Int("length", 32, "Password length"),
+		charsets: fs.String("charset", "lower,upper,digits", "Password character classes, each used at least once: lower, upper, digits, symbols"),
+		bytes:    fs.Int("bytes", 32, "Number of random bytes for hex and base64 values"),
+		bits:     fs.Int("bits", 4096, "RSA key size, 2048 to 16384"),
+		cn:       fs.String("cn", "", "Certificate common name (default: secret name)"),
+		hosts:    fs.String("hosts", "", "Comma separated certificate DNS names and IPs (default: common name)"),
+		validFor: fs.Duration("valid-for", 365*24*time.Hour, "Certificate validity"),
+	}
+}
+
+// options returns validated generator options for the secret.
+func (f *generateFlags) options(secret string) GenerateOptions {
+	opts := GenerateOptions{
+		Kind:       GeneratorKind(*f.kind),
+		Length:     *f.length,
+		Bytes:      *f.bytes,
+		Bits:       *f.bits,
+		CommonName: *f.cn,
+		ValidFor:   *f.validFor,
+	}
+	if opts.CommonName == "" {
+		opts.CommonName = secret
+	}
+	for _, c := range strings.Split(*f.charsets, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			opts.Charsets = append(opts.Charsets, c)
+		}
+	}
+	for _, h := range strings.Split(*f.hosts, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			opts.Hosts = append(opts.Hosts, h)
+		}
+	}
+	if err := opts.Validate(); err != nil {
+		fatalf("Error in generator options: %v", err)
+	}
+	return opts
+}
+
+func runGenerate(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	gen := addGenerateFlags(fs, GeneratePassword)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: secctl generate <namespace/secret> <key> --kind <kind> [options]")
+		fmt.Fprintln(fs.Output(), "SSH key pairs are written to <key> and <key>.pub, TLS pairs to <key>.crt and <key>.key.")
+		fs.PrintDefaults()
+	}
+	args = parseCommandFlags(fs, args)
+	if len(args) != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	a := newClientApp(cfg)
+	namespace, name := parseSecretRef(cfg, a.k8s, args[0])
+	key := args[1]
+	opts := gen.options(name)
+
+	secret, err := withTimeoutCtx(func(ctx context.Context) (*Secret, error) {
+		return a.k8s.GetSecret(ctx, namespace, name)
+	})
+	if err != nil {
+		fatalf("Error loading secret: %v", err)
+	}
+	a.remember(RecentItem{Namespace: namespace, Secret: name, Key: key})
+	a.generateKey(namespace, name, key, secret, opts)
+}
+
+func runRotate(cfg *Config, args []string) {
+	fs := flag.NewFlagSet("rotate", flag.ExitOnError)
+	gen := addGenerateFlags(fs, "")
+	suffix := fs.String("previous-suffix", cfg.PreviousKeySuffix, "Suffix of the key keeping the previous value")
+	finish := fs.Bool("finish", false, "Remove the previous value after rollout is done")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: secctl rotate <namespace/secret> <key> [--kind <kind> [options]] [--finish]")
+		fmt.Fprintln(fs.Output(), "New value is generated with --kind or asked for, the current one is kept in <key><suffix>.")
+		fs.PrintDefaults()
+	}
+	args = parseCommandFlags(fs, args)
+	if len(args) != 2 || *suffix == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	a := newClientApp(cfg)
+	namespace, name := parseSecretRef(cfg, a.k8s, args[0])
+	key := args[1]
+	var opts *GenerateOptions
+	if *gen.kind != "" && !*finish {
+		o := gen.options(name)
+		if len(GeneratedKeys(o.Kind, key)) > 1 {
+			fatalf("Key pairs of kind '%s' can't be rotated, use a single value kind", o.Kind)
+		}
+		opts = &o
+	}
+
+	secret, err := withTimeoutCtx(func(ctx context.Context) (*Secret, error) {
+		return a.k8s.GetSecret(ctx, namespace, name)
+	})
+	if err != nil {
+		fatalf("Error loading secret: %v", err)
+	}
+	if _, ok := secret.Data[key]; !ok {
+		fatalf("Key '%s' not found in secret '%s' in namespace '%s'", key, name, namespace)
+	}
+	a.remember(RecentItem{Namespace: namespace, Secret: name, Key: key})
+	if *finish {
+		a.finishRotation(namespace, name, key, key+*suffix, secret)
+		return
+	}
+	a.rotateKey(namespace, name, key, key+*suffix, secret, opts)
+}
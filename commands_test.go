@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"slices"
+	"testing"
+)
+
+func TestParseCommandFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	kind := fs.String("kind", "", "")
+	force := fs.Bool("force", false, "")
+
+	args := parseCommandFlags(fs, []string{"ns/secret", "--kind", "uuid", "key", "--force"})
+	if !slices.Equal(args, []string{"ns/secret", "key"}) {
+		t.Errorf("unexpected positional arguments: %v", args)
+	}
+	if *kind != "uuid" || !*force {
+		t.Errorf("expected kind 'uuid' and force, got '%s' and %v", *kind, *force)
+	}
+}
+
+func TestGenerateFlagsOptions(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	gen := addGenerateFlags(fs, GeneratePassword)
+	if err := fs.Parse([]string{"--charset", "lower,,digits, ", "--hosts", "a.example.com,"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opts := gen.options("mysecret")
+	if !slices.Equal(opts.Charsets, []string{"lower", "digits"}) {
+		t.Errorf("expected charsets without empty elements, got %q", opts.Charsets)
+	}
+	if !slices.Equal(opts.Hosts, []string{"a.example.com"}) || opts.CommonName != "mysecret" {
+		t.Errorf("unexpected certificate options: %q, %q", opts.Hosts, opts.CommonName)
+	}
+}
@@ -1,17 +1,217 @@
 package main
 
-import "flag"
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
 
 type Config struct {
 	EditorPath string
 	KubeConfig string
+	ConfigPath string
+	Namespace  string
+	Secret     string
+	Key        string
+
+	AllNamespaces bool
+	Filter        SecretFilter
+	// ConfigMap selects ConfigMaps instead of secrets.
+	ConfigMap bool
+
+	EditMetadata  bool
+	ShowConsumers bool
+
+	Restart        bool
+	WaitRollout    bool
+	RolloutTimeout time.Duration
+
+	Force  bool
+	Reason string
+	// RemoveBackup removes backup of recreated immutable secret.
+	RemoveBackup bool
+
+	SealCert   string
+	SealScope  SealScope
+	SealOutput string
+
+	Mask               MaskMode
+	Timeout            time.Duration
+	FavoriteNamespaces []string
+	FavoriteSecrets    []string
+	Contexts           map[string]ContextRules
+	LineEndings        []LineEndingRule
+	PreviousKeySuffix  string
+
+	// Command is a subcommand with its arguments, empty for interactive mode.
+	Command []string
 
 	showVersion bool
 }
 
-func (c *Config) Parse() {
-	flag.StringVar(&c.EditorPath, "editor", "", "Path to the text editor (default: $EDITOR)")
-	flag.StringVar(&c.KubeConfig, "kubeconfig", "", "Path to the kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
-	flag.BoolVar(&c.showVersion, "version", false, "Show version information and exit")
-	flag.Parse()
+// ContextRules are protections applied to a kubeconfig context.
+type ContextRules struct {
+	ReadOnly          bool     `json:"readOnly,omitempty"`
+	TypedConfirmation bool     `json:"typedConfirmation,omitempty"`
+	HiddenNamespaces  []string `json:"hiddenNamespaces,omitempty"`
+}
+
+// FileConfig is the content of user configuration file.
+type FileConfig struct {
+	Editor             string                  `json:"editor,omitempty"`
+	Mask               MaskMode                `json:"mask,omitempty"`
+	Timeout            *metav1.Duration        `json:"timeout,omitempty"`
+	RolloutTimeout     *metav1.Duration        `json:"rolloutTimeout,omitempty"`
+	FavoriteNamespaces []string                `json:"favoriteNamespaces,omitempty"`
+	FavoriteSecrets    []string                `json:"favoriteSecrets,omitempty"`
+	Contexts           map[string]ContextRules `json:"contexts,omitempty"`
+	LineEndings        []LineEndingRule        `json:"lineEndings,omitempty"`
+	PreviousKeySuffix  string                  `json:"previousKeySuffix,omitempty"`
+}
+
+func (c *Config) Parse() error {
+	return c.parse(flag.CommandLine, os.Args[1:])
+}
+
+func (c *Config) parse(fs *flag.FlagSet, args []string) error {
+	c.Mask = MaskNone
+	c.SealScope = SealStrict
+	c.PreviousKeySuffix = defaultPreviousKeySuffix
+
+	fs.StringVar(&c.EditorPath, "editor", "", "Path to the text editor (default: $EDITOR)")
+	fs.StringVar(&c.KubeConfig, "kubeconfig", "", "Path to the kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	fs.StringVar(&c.ConfigPath, "config", "", "Path to the secctl config file (default: ~/.config/secctl/config.yaml)")
+	fs.StringVar(&c.Namespace, "namespace", "", "Namespace to use instead of selecting one")
+	fs.StringVar(&c.Namespace, "n", "", "Shorthand for -namespace")
+	fs.StringVar(&c.Secret, "secret", "", "Secret name or namespace/name to use instead of selecting one")
+	fs.StringVar(&c.Key, "key", "", "Secret key to use instead of selecting one")
+	fs.BoolVar(&c.ConfigMap, "configmap", false, "Edit ConfigMaps instead of secrets")
+	fs.BoolVar(&c.AllNamespaces, "all-namespaces", false, "Select secret from all namespaces")
+	fs.StringVar(&c.Filter.Type, "type", "", "Show only secrets of the given type")
+	fs.StringVar(&c.Filter.LabelSelector, "selector", "", "Label selector to filter secrets")
+	fs.StringVar(&c.Filter.LabelSelector, "l", "", "Shorthand for -selector")
+	fs.StringVar(&c.Filter.FieldSelector, "field-selector", "", "Field selector to filter secrets")
+	fs.BoolVar(&c.Filter.NoDefault, "no-filter", false, "Show Helm release and service account token secrets hidden by default")
+	fs.BoolVar(&c.EditMetadata, "metadata", false, "Edit secret labels and annotations instead of data")
+	fs.BoolVar(&c.ShowConsumers, "consumers", false, "List workloads that reference the secret and exit")
+	fs.BoolVar(&c.Restart, "restart", false, "Restart workloads using the secret after save without asking")
+	fs.BoolVar(&c.WaitRollout, "wait", false, "Wait for rollout of restarted workloads to complete")
+	fs.DurationVar(&c.RolloutTimeout, "rollout-timeout", 5*time.Minute, "Timeout for waiting on rollout status")
+	fs.BoolVar(&c.Force, "force", false, "Remove or rename keys even if workloads still reference them")
+	fs.BoolVar(&c.RemoveBackup, "remove-backup", false, "Remove backup of the original immutable secret once it is recreated")
+	fs.StringVar(&c.Reason, "reason", "", "Reason for the change recorded in secret annotations")
+	fs.StringVar(&c.SealCert, "seal-cert", "", "Seal changes offline into a SealedSecret with the controller certificate instead of saving them")
+	fs.Var(&c.SealScope, "seal-scope", "Scope of the SealedSecret: strict, namespace-wide or cluster-wide")
+	fs.StringVar(&c.SealOutput, "seal-output", "", "File to write the SealedSecret to (default: stdout)")
+	fs.Var(&c.Mask, "mask", "How to show secret values: none, partial or full")
+	fs.DurationVar(&c.Timeout, "timeout", 30*time.Second, "Timeout for Kubernetes API requests")
+	fs.BoolVar(&c.showVersion, "version", false, "Show version information and exit")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	c.Command = fs.Args()
+
+	if c.ConfigPath == "" {
+		dir, err := ConfigDir()
+		if err != nil {
+			return err
+		}
+		c.ConfigPath = filepath.Join(dir, "config.yaml")
+	}
+	file, err := LoadFileConfig(c.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	c.merge(file, set)
+	return nil
+}
+
+// merge applies file config values which were not set by flags.
+func (c *Config) merge(file *FileConfig, set map[string]bool) {
+	if !set["editor"] && file.Editor != "" {
+		c.EditorPath = file.Editor
+	}
+	if !set["mask"] && file.Mask != "" {
+		c.Mask = file.Mask
+	}
+	if !set["timeout"] && file.Timeout != nil {
+		c.Timeout = file.Timeout.Duration
+	}
+	if !set["rollout-timeout"] && file.RolloutTimeout != nil {
+		c.RolloutTimeout = file.RolloutTimeout.Duration
+	}
+	c.FavoriteNamespaces = file.FavoriteNamespaces
+	c.FavoriteSecrets = file.FavoriteSecrets
+	c.Contexts = file.Contexts
+	c.LineEndings = file.LineEndings
+	if file.PreviousKeySuffix != "" {
+		c.PreviousKeySuffix = file.PreviousKeySuffix
+	}
+}
+
+// Effective returns effective configuration in config file format.
+func (c *Config) Effective() *FileConfig {
+	return &FileConfig{
+		Editor:             c.EditorPath,
+		Mask:               c.Mask,
+		Timeout:            &metav1.Duration{Duration: c.Timeout},
+		RolloutTimeout:     &metav1.Duration{Duration: c.RolloutTimeout},
+		FavoriteNamespaces: c.FavoriteNamespaces,
+		FavoriteSecrets:    c.FavoriteSecrets,
+		Contexts:           c.Contexts,
+		LineEndings:        c.LineEndings,
+		PreviousKeySuffix:  c.PreviousKeySuffix,
+	}
+}
+
+// LineEndingPolicy returns line ending policy configured for key.
+func (c *Config) LineEndingPolicy(key string) LineEndingPolicy {
+	return MatchLineEndingPolicy(c.LineEndings, key)
+}
+
+// ContextRules returns rules configured for kubeconfig context.
+func (c *Config) ContextRules(context string) ContextRules {
+	return c.Contexts[context]
+}
+
+// IsHidden checks if namespace is hidden by context rules.
+func (r ContextRules) IsHidden(namespace string) bool {
+	return slices.Contains(r.HiddenNamespaces, namespace)
+}
+
+// LoadFileConfig reads config file. Missing file results in empty config.
+func LoadFileConfig(p string) (*FileConfig, error) {
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return &FileConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	var cfg FileConfig
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse config file '%s': %w", p, err)
+	}
+	if cfg.Mask != "" {
+		if err := cfg.Mask.Validate(); err != nil {
+			return nil, fmt.Errorf("parse config file '%s': %w", p, err)
+		}
+	}
+	for _, r := range cfg.LineEndings {
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("parse config file '%s': %w", p, err)
+		}
+	}
+	return &cfg, nil
 }
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return p
+}
+
+func parseTestConfig(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+
+	var cfg Config
+	err := cfg.parse(flag.NewFlagSet("test", flag.ContinueOnError), args)
+	return &cfg, err
+}
+
+func TestConfigParse_Defaults(t *testing.T) {
+	cfg, err := parseTestConfig(t, "-config", filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Mask != MaskNone {
+		t.Errorf("expected mask 'none', got '%s'", cfg.Mask)
+	}
+	if cfg.Timeout != 30*time.Second {
+		t.Errorf("expected timeout 30s, got %s", cfg.Timeout)
+	}
+	if cfg.RolloutTimeout != 5*time.Minute {
+		t.Errorf("expected rollout timeout 5m, got %s", cfg.RolloutTimeout)
+	}
+	if cfg.PreviousKeySuffix != "_previous" {
+		t.Errorf("expected previous key suffix '_previous', got '%s'", cfg.PreviousKeySuffix)
+	}
+}
+
+func TestConfigParse_File(t *testing.T) {
+	p := writeTestConfig(t, `editor: /usr/bin/vim
+mask: partial
+timeout: 10s
+rolloutTimeout: 1m
+previousKeySuffix: _old
+favoriteNamespaces: [team-a, team-b]
+contexts:
+  prod:
+    readOnly: true
+    typedConfirmation: true
+    hiddenNamespaces: [kube-system]
+lineEndings:
+  - key: "*.pem"
+    policy: ignore
+  - key: "*"
+    policy: keep
+`)
+
+	cfg, err := parseTestConfig(t, "-config", p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.EditorPath != "/usr/bin/vim" {
+		t.Errorf("expected editor '/usr/bin/vim', got '%s'", cfg.EditorPath)
+	}
+	if cfg.Mask != MaskPartial {
+		t.Errorf("expected mask 'partial', got '%s'", cfg.Mask)
+	}
+	if cfg.Timeout != 10*time.Second {
+		t.Errorf("expected timeout 10s, got %s", cfg.Timeout)
+	}
+	if cfg.RolloutTimeout != time.Minute {
+		t.Errorf("expected rollout timeout 1m, got %s", cfg.RolloutTimeout)
+	}
+	if cfg.PreviousKeySuffix != "_old" {
+		t.Errorf("expected previous key suffix '_old', got '%s'", cfg.PreviousKeySuffix)
+	}
+	if !slices.Equal(cfg.FavoriteNamespaces, []string{"team-a", "team-b"}) {
+		t.Errorf("unexpected favorite namespaces: %v", cfg.FavoriteNamespaces)
+	}
+
+	rules := cfg.ContextRules("prod")
+	if !rules.ReadOnly || !rules.TypedConfirmation {
+		t.Errorf("unexpected prod rules: %+v", rules)
+	}
+	if !rules.IsHidden("kube-system") || rules.IsHidden("default") {
+		t.Errorf("unexpected hidden namespaces: %v", rules.HiddenNamespaces)
+	}
+	if rules := cfg.ContextRules("dev"); rules.ReadOnly {
+		t.Error("expected no rules for unknown context")
+	}
+	if p := cfg.LineEndingPolicy("tls.pem"); p != LineEndingIgnore {
+		t.Errorf("expected line ending policy 'ignore', got '%s'", p)
+	}
+	if p := cfg.LineEndingPolicy("token"); p != LineEndingKeep {
+		t.Errorf("expected line ending policy 'keep', got '%s'", p)
+	}
+}
+
+func TestConfigParse_FlagsOverrideFile(t *testing.T) {
+	p := writeTestConfig(t, "editor: /usr/bin/vim\nmask: full\ntimeout: 10s\n")
+
+	cfg, err := parseTestConfig(t, "-config", p, "-editor", "/usr/bin/nano", "-mask", "none", "-timeout", "1m")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.EditorPath != "/usr/bin/nano" {
+		t.Errorf("expected editor '/usr/bin/nano', got '%s'", cfg.EditorPath)
+	}
+	if cfg.Mask != MaskNone {
+		t.Errorf("expected mask 'none', got '%s'", cfg.Mask)
+	}
+	if cfg.Timeout != time.Minute {
+		t.Errorf("expected timeout 1m, got %s", cfg.Timeout)
+	}
+}
+
+func TestConfigParse_Command(t *testing.T) {
+	cfg, err := parseTestConfig(t, "-config", filepath.Join(t.TempDir(), "missing.yaml"), "config", "view")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(cfg.Command, []string{"config", "view"}) {
+		t.Errorf("unexpected command: %v", cfg.Command)
+	}
+}
+
+func TestLoadFileConfig_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":               "editr: vim\n",
+		"invalid mask":                "mask: sometimes\n",
+		"invalid line ending policy":  "lineEndings: [{key: '*', policy: never}]\n",
+		"invalid line ending pattern": "lineEndings: [{key: '[', policy: keep}]\n",
+		"invalid yaml":                "contexts: [\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadFileConfig(writeTestConfig(t, content)); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var configMapsResource = corev1.SchemeGroupVersion.WithResource("configmaps")
+
+// ConfigMap is ConfigMap data with attributes affecting how it can be edited.
+type ConfigMap struct {
+	Data       map[string]string
+	BinaryData map[string][]byte
+	// Immutable ConfigMaps can't be updated.
+	Immutable bool
+}
+
+// Values returns values of both data and binaryData keys.
+func (c *ConfigMap) Values() SecretData {
+	values := make(SecretData, len(c.Data)+len(c.BinaryData))
+	for k, v := range c.Data {
+		values[k] = []byte(v)
+	}
+	maps.Copy(values, c.BinaryData)
+	return values
+}
+
+// IsBinary checks if the key is stored in binaryData.
+func (c *ConfigMap) IsBinary(key string) bool {
+	_, ok := c.BinaryData[key]
+	return ok
+}
+
+// ListConfigMaps lists ConfigMap names in namespace. Only metadata
+// is fetched, page by page.
+func (k *K8SClient) ListConfigMaps(ctx context.Context, namespace string) ([]string, error) {
+	var items []string
+	opts := metav1.ListOptions{Limit: listPageSize}
+	for {
+		page, err := k.metadata.Resource(configMapsResource).Namespace(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("list configmaps in namespace '%s': %w", namespace, err)
+		}
+		for i := range page.Items {
+			items = append(items, page.Items[i].Name)
+		}
+		if page.Continue == "" {
+			return items, nil
+		}
+		opts.Continue = page.Continue
+	}
+}
+
+func (k *K8SClient) GetConfigMap(ctx context.Context, namespace, name string) (*ConfigMap, error) {
+	cm, err := k.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get configmap '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	return &ConfigMap{
+		Data:       cm.Data,
+		BinaryData: cm.BinaryData,
+		Immutable:  cm.Immutable != nil && *cm.Immutable,
+	}, nil
+}
+
+// SaveConfigMap sets value of the ConfigMap key. Binary keys stay in
+// binaryData, other keys are moved there if the value is not valid
+// UTF-8, since data holds only strings.
+func (k *K8SClient) SaveConfigMap(ctx context.Context, namespace, name, key string, data []byte) error {
+	cm, err := k.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if _, binary := cm.BinaryData[key]; binary || !utf8.Valid(data) {
+		if cm.BinaryData == nil {
+			cm.BinaryData = make(map[string][]byte)
+		}
+		delete(cm.Data, key)
+		cm.BinaryData[key] = data
+	} else {
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[key] = string(data)
+	}
+	k.stamp(ctx, &cm.ObjectMeta, key)
+
+	if _, err = k.clientset.CoreV1().ConfigMaps(namespace).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update configmap '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	metadatafake "k8s.io/client-go/metadata/fake"
+)
+
+func newTestConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Data:       map[string]string{"config.yaml": "debug: false\n"},
+		BinaryData: map[string][]byte{"logo.png": {0x89, 'P', 'N', 'G'}},
+	}
+}
+
+func TestListConfigMaps(t *testing.T) {
+	scheme := metadatafake.NewTestScheme()
+	if err := metav1.AddMetaToScheme(scheme); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	var objects []runtime.Object
+	for _, ref := range [][2]string{{"default", "app"}, {"default", "web"}, {"kube-system", "coredns"}} {
+		objects = append(objects, &metav1.PartialObjectMetadata{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: ref[0], Name: ref[1]},
+		})
+	}
+	client := &K8SClient{metadata: metadatafake.NewSimpleMetadataClient(scheme, objects...)}
+
+	configMaps, err := client.ListConfigMaps(context.Background(), "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(configMaps) != 2 {
+		t.Errorf("expected 2 configmaps in default namespace, got %v", configMaps)
+	}
+}
+
+func TestGetConfigMap(t *testing.T) {
+	client := &K8SClient{clientset: fake.NewSimpleClientset(newTestConfigMap())}
+
+	cm, err := client.GetConfigMap(context.Background(), "default", "app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	values := cm.Values()
+	if len(values) != 2 || string(values["config.yaml"]) != "debug: false\n" || len(values["logo.png"]) != 4 {
+		t.Errorf("unexpected values: %v", values)
+	}
+	if cm.IsBinary("config.yaml") || !cm.IsBinary("logo.png") {
+		t.Error("expected only logo.png to be binary")
+	}
+
+	if _, err := client.GetConfigMap(context.Background(), "default", "missing"); err == nil {
+		t.Error("expected error for missing configmap, got nil")
+	}
+}
+
+func TestSaveConfigMap(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    string
+		value  []byte
+		binary bool
+	}{
+		{"data", "config.yaml", []byte("debug: true\n"), false},
+		{"binary data", "logo.png", []byte("text"), true},
+		{"new key", "extra", []byte("value"), false},
+		{"invalid utf-8 moves to binary data", "config.yaml", []byte{0xff, 0xfe}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClientset := fake.NewSimpleClientset(newTestConfigMap())
+			client := &K8SClient{clientset: fakeClientset}
+			ctx := context.Background()
+
+			if err := client.SaveConfigMap(ctx, "default", "app", tt.key, tt.value); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			cm, err := fakeClientset.CoreV1().ConfigMaps("default").Get(ctx, "app", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_, inData := cm.Data[tt.key]
+			_, inBinary := cm.BinaryData[tt.key]
+			if inBinary != tt.binary || inData == tt.binary {
+				t.Errorf("expected key '%s' binary %v, got data %v, binaryData %v", tt.key, tt.binary, inData, inBinary)
+			}
+			got := []byte(cm.Data[tt.key])
+			if tt.binary {
+				got = cm.BinaryData[tt.key]
+			}
+			if string(got) != string(tt.value) {
+				t.Errorf("expected value %q, got %q", tt.value, got)
+			}
+			if cm.Annotations[lastModifiedKeysAnnotation] != tt.key {
+				t.Errorf("expected changed key '%s', got '%s'", tt.key, cm.Annotations[lastModifiedKeysAnnotation])
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Secret reference sources in pod spec.
+const (
+	RefEnvFrom          = "envFrom"
+	RefSecretKeyRef     = "secretKeyRef"
+	RefVolume           = "volume"
+	RefProjectedVolume  = "projected"
+	RefImagePullSecrets = "imagePullSecrets"
+)
+
+// SecretRef is a single reference to a secret from a pod spec.
+type SecretRef struct {
+	// Source is one of Ref* constants.
+	Source string
+	// Container name, empty for pod-level references (volumes, image pull secrets).
+	Container string
+	// Volume name for volume references.
+	Volume string
+	// Key is referenced secret key, empty if the whole secret is referenced.
+	Key      string
+	Optional bool
+}
+
+// Consumer is a workload which references a secret.
+type Consumer struct {
+	Kind string
+	Name string
+	Refs []SecretRef
+}
+
+// Keys returns sorted unique keys used by consumer. It returns nil
+// if consumer uses the whole secret.
+func (c Consumer) Keys() []string {
+	var keys []string
+	for _, ref := range c.Refs {
+		if ref.Key == "" {
+			return nil
+		}
+		keys = append(keys, ref.Key)
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
+
+func (c Consumer) String() string {
+	keys := c.Keys()
+	if keys == nil {
+		return fmt.Sprintf("%s/%s: all keys", c.Kind, c.Name)
+	}
+	return fmt.Sprintf("%s/%s: %s", c.Kind, c.Name, strings.Join(keys, ", "))
+}
+
+// FormatConsumers renders consumers list for the terminal.
+func FormatConsumers(consumers []Consumer) string {
+	if len(consumers) == 0 {
+		return "No workloads reference this secret."
+	}
+	var sb strings.Builder
+	sb.WriteString("Workloads referencing this secret:")
+	for _, c := range consumers {
+		sb.WriteString("\n  - ")
+		sb.WriteString(c.String())
+	}
+	return sb.String()
+}
+
+// FindSecretConsumers scans workloads in namespace for references to secret.
+// Pods and Jobs controlled by scanned workloads are skipped since their
+// owners are reported instead.
+func (k *K8SClient) FindSecretConsumers(ctx context.Context, namespace, secret string) ([]Consumer, error) {
+	var workloads []podWorkload
+	for _, list := range []func(context.Context, string) ([]podWorkload, error){
+		k.listPodWorkloads, k.listAppWorkloads, k.listBatchWorkloads,
+	} {
+		items, err := list(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		workloads = append(workloads, items...)
+	}
+
+	var consumers []Consumer
+	for _, w := range workloads {
+		if refs := secretRefsFromPodSpec(w.spec, secret); len(refs) > 0 {
+			consumers = append(consumers, Consumer{Kind: w.kind, Name: w.name, Refs: refs})
+		}
+	}
+	return consumers, nil
+}
+
+// podWorkload is a workload with its pod spec.
+type podWorkload struct {
+	kind string
+	name string
+	spec *corev1.PodSpec
+}
+
+// listPodWorkloads lists Pods which are not controlled by scanned
+// workloads, e.g. standalone Pods or Pods of bare ReplicaSets.
+func (k *K8SClient) listPodWorkloads(ctx context.Context, namespace string) ([]podWorkload, error) {
+	replicaSets, err := k.deploymentReplicaSets(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	var workloads []podWorkload
+	pods, err := k.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list pods in namespace '%s': %w", namespace, err)
+	}
+	for i := range pods.Items {
+		owner := metav1.GetControllerOf(&pods.Items[i])
+		if owner == nil || !scannedKinds[owner.Kind] && !replicaSets[owner.UID] {
+			workloads = append(workloads, podWorkload{"Pod", pods.Items[i].Name, &pods.Items[i].Spec})
+		}
+	}
+	return workloads, nil
+}
+
+// scannedKinds are owner kinds whose all objects are scanned. Jobs of
+// CronJobs are scanned through their owner, other Jobs themselves.
+var scannedKinds = map[string]bool{"StatefulSet": true, "DaemonSet": true, "Job": true}
+
+// deploymentReplicaSets returns UIDs of ReplicaSets controlled by
+// Deployments. Bare ReplicaSets and ones of other controllers, e.g.
+// Argo Rollouts, are not scanned.
+func (k *K8SClient) deploymentReplicaSets(ctx context.Context, namespace string) (map[types.UID]bool, error) {
+	replicaSets, err := k.clientset.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list replicasets in namespace '%s': %w", namespace, err)
+	}
+	res := make(map[types.UID]bool)
+	for i := range replicaSets.Items {
+		if owner := metav1.GetControllerOf(&replicaSets.Items[i]); owner != nil && owner.Kind == "Deployment" {
+			res[replicaSets.Items[i].UID] = true
+		}
+	}
+	return res, nil
+}
+
+// listBatchWorkloads lists Jobs not controlled by CronJobs, and CronJobs.
+func (k *K8SClient) listBatchWorkloads(ctx context.Context, namespace string) ([]podWorkload, error) {
+	var workloads []podWorkload
+	jobs, err := k.clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list jobs in namespace '%s': %w", namespace, err)
+	}
+	for i := range jobs.Items {
+		if owner := metav1.GetControllerOf(&jobs.Items[i]); owner == nil || owner.Kind != "CronJob" {
+			workloads = append(workloads, podWorkload{"Job", jobs.Items[i].Name, &jobs.Items[i].Spec.Template.Spec})
+		}
+	}
+
+	cronJobs, err := k.clientset.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list cronjobs in namespace '%s': %w", namespace, err)
+	}
+	for i := range cronJobs.Items {
+		workloads = append(workloads, podWorkload{"CronJob", cronJobs.Items[i].Name,
+			&cronJobs.Items[i].Spec.JobTemplate.Spec.Template.Spec})
+	}
+	return workloads, nil
+}
+
+// listAppWorkloads lists Deployments, StatefulSets and DaemonSets.
+func (k *K8SClient) listAppWorkloads(ctx context.Context, namespace string) ([]podWorkload, error) {
+	var workloads []podWorkload
+	apps := k.clientset.AppsV1()
+	deployments, err := apps.Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list deployments in namespace '%s': %w", namespace, err)
+	}
+	for i := range deployments.Items {
+		workloads = append(workloads, podWorkload{"Deployment", deployments.Items[i].Name,
+			&deployments.Items[i].Spec.Template.Spec})
+	}
+
+	statefulSets, err := apps.StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list statefulsets in namespace '%s': %w", namespace, err)
+	}
+	for i := range statefulSets.Items {
+		workloads = append(workloads, podWorkload{"StatefulSet", statefulSets.Items[i].Name,
+			&statefulSets.Items[i].Spec.Template.Spec})
+	}
+
+	daemonSets, err := apps.DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list daemonsets in namespace '%s': %w", namespace, err)
+	}
+	for i := range daemonSets.Items {
+		workloads = append(workloads, podWorkload{"DaemonSet", daemonSets.Items[i].Name,
+			&daemonSets.Items[i].Spec.Template.Spec})
+	}
+	return workloads, nil
+}
+
+func secretRefsFromPodSpec(spec *corev1.PodSpec, secret string) []SecretRef {
+	var refs []SecretRef
+	for _, s := range spec.ImagePullSecrets {
+		if s.Name == secret {
+			refs = append(refs, SecretRef{Source: RefImagePullSecrets})
+		}
+	}
+	refs = append(refs, volumeSecretRefs(spec.Volumes, secret)...)
+	for _, c := range spec.InitContainers {
+		refs = append(refs, containerSecretRefs(&c, secret)...)
+	}
+	for _, c := range spec.Containers {
+		refs = append(refs, containerSecretRefs(&c, secret)...)
+	}
+	for _, c := range spec.EphemeralContainers {
+		refs = append(refs, containerSecretRefs((*corev1.Container)(&c.EphemeralContainerCommon), secret)...)
+	}
+	return refs
+}
+
+// volumeSecretRefs returns references from secret and projected volumes.
+func volumeSecretRefs(volumes []corev1.Volume, secret string) []SecretRef {
+	var refs []SecretRef
+	for _, vol := range volumes {
+		if s := vol.Secret; s != nil && s.SecretName == secret {
+			refs = append(refs, volumeRefs(RefVolume, vol.Name, s.Items, s.Optional)...)
+		}
+		if p := vol.Projected; p != nil {
+			for _, src := range p.Sources {
+				if s := src.Secret; s != nil && s.Name == secret {
+					refs = append(refs, volumeRefs(RefProjectedVolume, vol.Name, s.Items, s.Optional)...)
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// containerSecretRefs returns references from container environment.
+func containerSecretRefs(c *corev1.Container, secret string) []SecretRef {
+	var refs []SecretRef
+	for _, src := range c.EnvFrom {
+		if s := src.SecretRef; s != nil && s.Name == secret {
+			refs = append(refs, SecretRef{
+				Source:    RefEnvFrom,
+				Container: c.Name,
+				Optional:  isOptional(s.Optional),
+			})
+		}
+	}
+	for _, env := range c.Env {
+		if env.ValueFrom == nil {
+			continue
+		}
+		if s := env.ValueFrom.SecretKeyRef; s != nil && s.Name == secret {
+			refs = append(refs, SecretRef{
+				Source:    RefSecretKeyRef,
+				Container: c.Name,
+				Key:       s.Key,
+				Optional:  isOptional(s.Optional),
+			})
+		}
+	}
+	return refs
+}
+
+func volumeRefs(source, volume string, items []corev1.KeyToPath, optional *bool) []SecretRef {
+	if len(items) == 0 {
+		return []SecretRef{{Source: source, Volume: volume, Optional: isOptional(optional)}}
+	}
+	refs := make([]SecretRef, len(items))
+	for i, item := range items {
+		refs[i] = SecretRef{Source: source, Volume: volume, Key: item.Key, Optional: isOptional(optional)}
+	}
+	return refs
+}
+
+func isOptional(v *bool) bool {
+	return v != nil && *v
+}
+
+// BrokenRef is a required reference to a key which would be removed.
+type BrokenRef struct {
+	Consumer Consumer
+	Ref      SecretRef
+}
+
+func (b BrokenRef) String() string {
+	var where string
+	if b.Ref.Container != "" {
+		where = fmt.Sprintf("container '%s'", b.Ref.Container)
+	} else {
+		where = fmt.Sprintf("volume '%s'", b.Ref.Volume)
+	}
+	return fmt.Sprintf("%s/%s %s requires key '%s' (%s)",
+		b.Consumer.Kind, b.Consumer.Name, where, b.Ref.Key, b.Ref.Source)
+}
+
+// FindBrokenRefs returns non-optional references to removed keys.
+// Such workloads fail to start on the next restart.
+func FindBrokenRefs(consumers []Consumer, removedKeys ...string) []BrokenRef {
+	var broken []BrokenRef
+	for _, c := range consumers {
+		for _, ref := range c.Refs {
+			if ref.Key == "" || ref.Optional || !slices.Contains(removedKeys, ref.Key) {
+				continue
+			}
+			broken = append(broken, BrokenRef{Consumer: c, Ref: ref})
+		}
+	}
+	return broken
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSecretRefsFromPodSpec(t *testing.T) {
+	optional := true
+	spec := &corev1.PodSpec{
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "mysecret"}},
+		Volumes: []corev1.Volume{
+			{Name: "certs", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+				SecretName: "mysecret",
+				Items:      []corev1.KeyToPath{{Key: "tls.crt", Path: "tls.crt"}},
+			}}},
+			{Name: "other", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+				SecretName: "other",
+			}}},
+			{Name: "projected", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{{Secret: &corev1.SecretProjection{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "mysecret"},
+					Optional:             &optional,
+				}}},
+			}}},
+		},
+		InitContainers: []corev1.Container{{
+			Name: "init",
+			EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "mysecret"},
+			}}},
+		}},
+		EphemeralContainers: []corev1.EphemeralContainer{{EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name: "debug",
+			EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "mysecret"},
+			}}},
+		}}},
+		Containers: []corev1.Container{{
+			Name: "app",
+			Env: []corev1.EnvVar{
+				{Name: "PLAIN", Value: "value"},
+				{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "mysecret"},
+					Key:                  "password",
+				}}},
+			},
+		}},
+	}
+
+	refs := secretRefsFromPodSpec(spec, "mysecret")
+
+	expected := []SecretRef{
+		{Source: RefImagePullSecrets},
+		{Source: RefVolume, Volume: "certs", Key: "tls.crt"},
+		{Source: RefProjectedVolume, Volume: "projected", Optional: true},
+		{Source: RefEnvFrom, Container: "init"},
+		{Source: RefSecretKeyRef, Container: "app", Key: "password"},
+		{Source: RefEnvFrom, Container: "debug"},
+	}
+	if !slices.Equal(refs, expected) {
+		t.Errorf("expected refs %+v, got %+v", expected, refs)
+	}
+}
+
+func TestConsumerKeys(t *testing.T) {
+	c := Consumer{Kind: "Deployment", Name: "web", Refs: []SecretRef{
+		{Source: RefSecretKeyRef, Key: "password"},
+		{Source: RefVolume, Key: "ca.crt"},
+		{Source: RefSecretKeyRef, Key: "password"},
+	}}
+	if keys := c.Keys(); !slices.Equal(keys, []string{"ca.crt", "password"}) {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+	if s := c.String(); s != "Deployment/web: ca.crt, password" {
+		t.Errorf("unexpected string: %s", s)
+	}
+
+	c.Refs = append(c.Refs, SecretRef{Source: RefEnvFrom})
+	if keys := c.Keys(); keys != nil {
+		t.Errorf("expected nil keys for whole secret reference, got %v", keys)
+	}
+	if s := c.String(); s != "Deployment/web: all keys" {
+		t.Errorf("unexpected string: %s", s)
+	}
+}
+
+func TestFindSecretConsumers(t *testing.T) {
+	podSpec := corev1.PodSpec{
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "mysecret"}},
+	}
+	template := corev1.PodTemplateSpec{Spec: podSpec}
+	isController := true
+
+	fakeClientset := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "standalone", Namespace: "default"},
+			Spec:       podSpec,
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "web-abc",
+				Namespace: "default",
+				OwnerReferences: []metav1.OwnerReference{{
+					Kind: "ReplicaSet", Name: "web-123", UID: "rs-web", Controller: &isController,
+				}},
+			},
+			Spec: podSpec,
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "web-123",
+				Namespace: "default",
+				UID:       "rs-web",
+				OwnerReferences: []metav1.OwnerReference{{
+					Kind: "Deployment", Name: "web", Controller: &isController,
+				}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "canary-abc",
+				Namespace: "default",
+				OwnerReferences: []metav1.OwnerReference{{
+					Kind: "ReplicaSet", Name: "canary-123", UID: "rs-canary", Controller: &isController,
+				}},
+			},
+			Spec: podSpec,
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "canary-123",
+				Namespace: "default",
+				UID:       "rs-canary",
+				OwnerReferences: []metav1.OwnerReference{{
+					Kind: "Rollout", Name: "canary", Controller: &isController,
+				}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "migrate-abc",
+				Namespace: "default",
+				OwnerReferences: []metav1.OwnerReference{{
+					Kind: "Job", Name: "migrate", Controller: &isController,
+				}},
+			},
+			Spec: podSpec,
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Template: template},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "default"},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+			Spec:       appsv1.StatefulSetSpec{Template: template},
+		},
+		&appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "default"},
+			Spec:       appsv1.DaemonSetSpec{Template: template},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "default"},
+			Spec:       batchv1.JobSpec{Template: template},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backup-1",
+				Namespace: "default",
+				OwnerReferences: []metav1.OwnerReference{{
+					Kind: "CronJob", Name: "backup", Controller: &isController,
+				}},
+			},
+			Spec: batchv1.JobSpec{Template: template},
+		},
+		&batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"},
+			Spec: batchv1.CronJobSpec{JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{Template: template},
+			}},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-ns", Namespace: "kube-system"},
+			Spec:       appsv1.DeploymentSpec{Template: template},
+		},
+	)
+
+	client := &K8SClient{clientset: fakeClientset}
+	consumers, err := client.FindSecretConsumers(context.Background(), "default", "mysecret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, c := range consumers {
+		names = append(names, c.Kind+"/"+c.Name)
+	}
+	expected := []string{
+		"Pod/canary-abc",
+		"Pod/standalone",
+		"Deployment/web",
+		"StatefulSet/db",
+		"DaemonSet/agent",
+		"Job/migrate",
+		"CronJob/backup",
+	}
+	if !slices.Equal(names, expected) {
+		t.Errorf("expected consumers %v, got %v", expected, names)
+	}
+}
+
+func TestFormatConsumers_Empty(t *testing.T) {
+	if s := FormatConsumers(nil); s != "No workloads reference this secret." {
+		t.Errorf("unexpected output: %s", s)
+	}
+}
+
+func TestFindBrokenRefs(t *testing.T) {
+	consumers := []Consumer{
+		{Kind: "Deployment", Name: "web", Refs: []SecretRef{
+			{Source: RefSecretKeyRef, Container: "app", Key: "password"},
+			{Source: RefSecretKeyRef, Container: "app", Key: "username"},
+			{Source: RefSecretKeyRef, Container: "sidecar", Key: "password", Optional: true},
+			{Source: RefEnvFrom, Container: "app"},
+		}},
+		{Kind: "StatefulSet", Name: "db", Refs: []SecretRef{
+			{Source: RefVolume, Volume: "creds", Key: "password"},
+		}},
+	}
+
+	broken := FindBrokenRefs(consumers, "password")
+	if len(broken) != 2 {
+		t.Fatalf("expected 2 broken refs, got %d", len(broken))
+	}
+	expected := "Deployment/web container 'app' requires key 'password' (secretKeyRef)"
+	if s := broken[0].String(); s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+	expected = "StatefulSet/db volume 'creds' requires key 'password' (volume)"
+	if s := broken[1].String(); s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+
+	if broken := FindBrokenRefs(consumers, "unused"); len(broken) != 0 {
+		t.Errorf("expected no broken refs, got %v", broken)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// secretEditedReason is the reason of events recorded after secret changes.
+const secretEditedReason = "SecretEdited"
+
+// eventComponent is the source component of recorded events.
+const eventComponent = "secctl"
+
+// SecretEditedMessage describes the change of secret keys by actor
+// without exposing values.
+func SecretEditedMessage(keys []string, actor, toolVersion string) string {
+	changes := "metadata changed"
+	if len(keys) > 0 {
+		keys = slices.Sorted(slices.Values(keys))
+		changes = "changed keys: " + strings.Join(keys, ", ")
+	}
+	return fmt.Sprintf("Secret edited by %s with secctl %s, %s", actor, toolVersion, changes)
+}
+
+// RecordSecretEdited creates an Event involving the secret, so the change
+// shows up in `kubectl describe secret`.
+func (k *K8SClient) RecordSecretEdited(ctx context.Context, namespace, name string, keys []string) error {
+	secret, err := k.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+
+	now := metav1.NewTime(time.Now())
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: name + ".",
+			Namespace:    namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion:      "v1",
+			Kind:            "Secret",
+			Namespace:       namespace,
+			Name:            name,
+			UID:             secret.UID,
+			ResourceVersion: secret.ResourceVersion,
+		},
+		Reason:         secretEditedReason,
+		Message:        SecretEditedMessage(keys, k.Author(ctx), version),
+		Type:           corev1.EventTypeNormal,
+		Source:         corev1.EventSource{Component: eventComponent},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if _, err := k.clientset.CoreV1().Events(namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("create event for secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	return nil
+}
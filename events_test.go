@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSecretEditedMessage(t *testing.T) {
+	expected := "Secret edited by alice with secctl v1.0.0, changed keys: a, b"
+	if res := SecretEditedMessage([]string{"b", "a"}, "alice", "v1.0.0"); res != expected {
+		t.Errorf("expected %q, got %q", expected, res)
+	}
+	expected = "Secret edited by alice with secctl v1.0.0, metadata changed"
+	if res := SecretEditedMessage(nil, "alice", "v1.0.0"); res != expected {
+		t.Errorf("expected %q, got %q", expected, res)
+	}
+}
+
+func TestRecordSecretEdited(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", UID: "secret-uid"},
+		Data:       map[string][]byte{"password": []byte("secret123")},
+	})
+	client := &K8SClient{clientset: fakeClientset, user: "alice"}
+	ctx := context.Background()
+
+	if err := client.RecordSecretEdited(ctx, "default", "db", []string{"password"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events, err := fakeClientset.CoreV1().Events("default").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events.Items) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events.Items))
+	}
+	ev := events.Items[0]
+	if ev.Reason != secretEditedReason || ev.Type != corev1.EventTypeNormal {
+		t.Errorf("unexpected event reason and type: %s %s", ev.Reason, ev.Type)
+	}
+	if ev.InvolvedObject.Kind != "Secret" || ev.InvolvedObject.Name != "db" || ev.InvolvedObject.UID != "secret-uid" {
+		t.Errorf("unexpected involved object: %+v", ev.InvolvedObject)
+	}
+	if !strings.Contains(ev.Message, "password") || !strings.Contains(ev.Message, "alice") {
+		t.Errorf("expected message with key and actor, got %q", ev.Message)
+	}
+	if strings.Contains(ev.Message, "secret123") {
+		t.Errorf("expected message without values, got %q", ev.Message)
+	}
+}
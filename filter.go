@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// helmReleaseSecretType is the type of secrets storing Helm releases.
+const helmReleaseSecretType = "helm.sh/release.v1"
+
+// defaultHiddenSecretTypes are rarely edited secret types hidden
+// from listings by default.
+var defaultHiddenSecretTypes = []string{
+	helmReleaseSecretType,
+	string(corev1.SecretTypeServiceAccountToken),
+}
+
+// SecretFilter narrows down secrets listing.
+type SecretFilter struct {
+	Type          string
+	LabelSelector string
+	FieldSelector string
+	// NoDefault disables hiding of defaultHiddenSecretTypes.
+	NoDefault bool
+}
+
+// ListOptions converts filter to list options. Secret type is filtered
+// by server side field selector since metadata API doesn't return it.
+func (f SecretFilter) ListOptions() metav1.ListOptions {
+	var fieldSelectors []string
+	if f.FieldSelector != "" {
+		fieldSelectors = append(fieldSelectors, f.FieldSelector)
+	}
+	switch {
+	case f.Type != "":
+		fieldSelectors = append(fieldSelectors, "type="+f.Type)
+	case !f.NoDefault:
+		for _, t := range defaultHiddenSecretTypes {
+			fieldSelectors = append(fieldSelectors, "type!="+t)
+		}
+	}
+	return metav1.ListOptions{
+		LabelSelector: f.LabelSelector,
+		FieldSelector: strings.Join(fieldSelectors, ","),
+	}
+}
+
+func (f SecretFilter) String() string {
+	opts := f.ListOptions()
+	var parts []string
+	if opts.LabelSelector != "" {
+		parts = append(parts, fmt.Sprintf("labels: %s", opts.LabelSelector))
+	}
+	if opts.FieldSelector != "" {
+		parts = append(parts, fmt.Sprintf("fields: %s", opts.FieldSelector))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestSecretFilter_ListOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter SecretFilter
+		labels string
+		fields string
+	}{
+		{
+			name:   "default",
+			filter: SecretFilter{},
+			fields: "type!=helm.sh/release.v1,type!=kubernetes.io/service-account-token",
+		},
+		{
+			name:   "no default",
+			filter: SecretFilter{NoDefault: true},
+		},
+		{
+			name:   "type",
+			filter: SecretFilter{Type: "kubernetes.io/tls"},
+			fields: "type=kubernetes.io/tls",
+		},
+		{
+			name:   "selectors",
+			filter: SecretFilter{LabelSelector: "app=web", FieldSelector: "metadata.name=db", NoDefault: true},
+			labels: "app=web",
+			fields: "metadata.name=db",
+		},
+		{
+			name:   "field selector with default",
+			filter: SecretFilter{FieldSelector: "metadata.name=db"},
+			fields: "metadata.name=db,type!=helm.sh/release.v1,type!=kubernetes.io/service-account-token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.filter.ListOptions()
+			if opts.LabelSelector != tt.labels {
+				t.Errorf("expected label selector %q, got %q", tt.labels, opts.LabelSelector)
+			}
+			if opts.FieldSelector != tt.fields {
+				t.Errorf("expected field selector %q, got %q", tt.fields, opts.FieldSelector)
+			}
+		})
+	}
+}
+
+func TestSecretFilter_String(t *testing.T) {
+	f := SecretFilter{Type: "Opaque", LabelSelector: "app=web"}
+	if s := f.String(); s != "labels: app=web; fields: type=Opaque" {
+		t.Errorf("unexpected string: %s", s)
+	}
+}
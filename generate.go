@@ -0,0 +1,268 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
+)
+
+// GeneratorKind is a kind of generated value.
+type GeneratorKind string
+
+const (
+	GeneratePassword   GeneratorKind = "password"
+	GenerateHex        GeneratorKind = "hex"
+	GenerateBase64     GeneratorKind = "base64"
+	GenerateUUID       GeneratorKind = "uuid"
+	GenerateSSHRSA     GeneratorKind = "ssh-rsa"
+	GenerateSSHEd25519 GeneratorKind = "ssh-ed25519"
+	GenerateTLS        GeneratorKind = "tls"
+)
+
+// GeneratorKinds lists all generator kinds.
+var GeneratorKinds = []GeneratorKind{
+	GeneratePassword, GenerateHex, GenerateBase64, GenerateUUID,
+	GenerateSSHRSA, GenerateSSHEd25519, GenerateTLS,
+}
+
+// Password character classes.
+var passwordCharsets = map[string]string{
+	"lower":   "abcdefghijklmnopqrstuvwxyz",
+	"upper":   "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"digits":  "0123456789",
+	"symbols": "!#$%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+// GenerateOptions configures value generation. Zero values use defaults.
+type GenerateOptions struct {
+	Kind GeneratorKind
+	// Length of password in characters.
+	Length int
+	// Charsets are password character classes, each used at least once.
+	Charsets []string
+	// Bytes is the number of random bytes of hex and base64 values.
+	Bytes int
+	// Bits is the RSA key size.
+	Bits int
+	// CommonName and Hosts of self-signed certificate.
+	CommonName string
+	Hosts      []string
+	ValidFor   time.Duration
+}
+
+// RSA key sizes accepted for generated keys.
+const (
+	minRSABits = 2048
+	maxRSABits = 16384
+)
+
+// Validate checks generator kind and options.
+func (o GenerateOptions) Validate() error {
+	if !slices.Contains(GeneratorKinds, o.Kind) {
+		return fmt.Errorf("unknown generator kind '%s'", o.Kind)
+	}
+	for _, c := range o.Charsets {
+		if _, ok := passwordCharsets[c]; !ok {
+			return fmt.Errorf("unknown charset '%s', expected lower, upper, digits or symbols", c)
+		}
+	}
+	if o.Kind == GeneratePassword && o.Length > 0 && o.Length < len(o.Charsets) {
+		return fmt.Errorf("password length %d is less than number of charsets", o.Length)
+	}
+	if o.Bits != 0 && (o.Bits < minRSABits || o.Bits > maxRSABits) {
+		return fmt.Errorf("RSA key size %d is out of range %d-%d", o.Bits, minRSABits, maxRSABits)
+	}
+	return nil
+}
+
+// GeneratedKeys returns keys written by the generator for the key:
+// key pairs are written to two keys.
+func GeneratedKeys(kind GeneratorKind, key string) []string {
+	switch kind {
+	case GenerateSSHRSA, GenerateSSHEd25519:
+		return []string{key, key + ".pub"}
+	case GenerateTLS:
+		prefix := strings.TrimSuffix(strings.TrimSuffix(key, ".crt"), ".key")
+		return []string{prefix + ".crt", prefix + ".key"}
+	}
+	return []string{key}
+}
+
+// Generate generates value for the key. Key pairs produce two keys,
+// see GeneratedKeys.
+func Generate(key string, opts GenerateOptions) (SecretData, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	keys := GeneratedKeys(opts.Kind, key)
+	switch opts.Kind {
+	case GeneratePassword:
+		v, err := generatePassword(opts.Length, opts.Charsets)
+		return SecretData{key: v}, err
+	case GenerateHex, GenerateBase64:
+		b := make([]byte, defaultInt(opts.Bytes, 32))
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("generate random bytes: %w", err)
+		}
+		if opts.Kind == GenerateHex {
+			return SecretData{key: []byte(hex.EncodeToString(b))}, nil
+		}
+		return SecretData{key: []byte(base64.StdEncoding.EncodeToString(b))}, nil
+	case GenerateUUID:
+		return SecretData{key: []byte(uuid.NewString())}, nil
+	case GenerateSSHRSA, GenerateSSHEd25519:
+		private, public, err := generateSSHKey(opts.Kind, opts.Bits)
+		return SecretData{keys[0]: private, keys[1]: public}, err
+	case GenerateTLS:
+		cert, private, err := generateTLS(opts)
+		return SecretData{keys[0]: cert, keys[1]: private}, err
+	}
+	return nil, fmt.Errorf("unknown generator kind '%s'", opts.Kind)
+}
+
+func defaultInt(v, def int) int {
+	if v > 0 {
+		return v
+	}
+	return def
+}
+
+// generatePassword generates random password using at least one
+// character of each charset.
+func generatePassword(length int, charsets []string) ([]byte, error) {
+	if len(charsets) == 0 {
+		charsets = []string{"lower", "upper", "digits"}
+	}
+	length = defaultInt(length, 32)
+
+	var all string
+	res := make([]byte, 0, length)
+	for _, c := range charsets {
+		chars := passwordCharsets[c]
+		all += chars
+		ch, err := randomChar(chars)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, ch)
+	}
+	for len(res) < length {
+		ch, err := randomChar(all)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, ch)
+	}
+	// shuffle so required characters are not always first
+	for i := len(res) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return nil, fmt.Errorf("generate password: %w", err)
+		}
+		res[i], res[j.Int64()] = res[j.Int64()], res[i]
+	}
+	return res, nil
+}
+
+func randomChar(chars string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+	if err != nil {
+		return 0, fmt.Errorf("generate password: %w", err)
+	}
+	return chars[n.Int64()], nil
+}
+
+// generateSSHKey returns OpenSSH private key and authorized_keys
+// formatted public key.
+func generateSSHKey(kind GeneratorKind, bits int) ([]byte, []byte, error) {
+	var private any
+	var public any
+	if kind == GenerateSSHRSA {
+		key, err := rsa.GenerateKey(rand.Reader, defaultInt(bits, 4096))
+		if err != nil {
+			return nil, nil, fmt.Errorf("generate RSA key: %w", err)
+		}
+		private, public = key, &key.PublicKey
+	} else {
+		pub, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("generate Ed25519 key: %w", err)
+		}
+		private, public = key, pub
+	}
+
+	block, err := ssh.MarshalPrivateKey(private, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal SSH private key: %w", err)
+	}
+	sshPublic, err := ssh.NewPublicKey(public)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal SSH public key: %w", err)
+	}
+	return pem.EncodeToMemory(block), ssh.MarshalAuthorizedKey(sshPublic), nil
+}
+
+// generateTLS returns self-signed certificate and its ECDSA P-256
+// private key in PEM format.
+func generateTLS(opts GenerateOptions) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate TLS key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate serial number: %w", err)
+	}
+	validFor := opts.ValidFor
+	if validFor <= 0 {
+		validFor = 365 * 24 * time.Hour
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: opts.CommonName},
+		NotBefore:             now,
+		NotAfter:              now.Add(validFor),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	hosts := opts.Hosts
+	if len(hosts) == 0 && opts.CommonName != "" {
+		hosts = []string{opts.CommonName}
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal TLS key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// helmReleaseKey is the key of Helm release secret holding the release.
+const helmReleaseKey = "release"
+
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// HelmRelease is a subset of Helm release stored in release secrets.
+type HelmRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		Status       string    `json:"status"`
+		Description  string    `json:"description"`
+		LastDeployed time.Time `json:"last_deployed"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			AppVersion string `json:"appVersion"`
+		} `json:"metadata"`
+	} `json:"chart"`
+	// Config is the values supplied by user on install or upgrade.
+	Config   map[string]any `json:"config"`
+	Manifest string         `json:"manifest"`
+}
+
+// DecodeHelmRelease decodes release key of Helm release secret:
+// base64 encoded, usually gzipped, JSON release.
+func DecodeHelmRelease(data []byte) (*HelmRelease, error) {
+	raw, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode base64: %w", err)
+	}
+	if bytes.HasPrefix(raw, gzipMagic) {
+		r, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("decompress release: %w", err)
+		}
+		defer r.Close()
+		if raw, err = io.ReadAll(r); err != nil {
+			return nil, fmt.Errorf("decompress release: %w", err)
+		}
+	}
+	var rel HelmRelease
+	if err := json.Unmarshal(raw, &rel); err != nil {
+		return nil, fmt.Errorf("parse release: %w", err)
+	}
+	return &rel, nil
+}
+
+// Format renders release summary, values and manifest. Data of Secrets
+// in manifest is masked with mask mode.
+func (r *HelmRelease) Format(mask MaskMode) (string, error) {
+	values := []byte("{}\n")
+	if len(r.Config) > 0 {
+		var err error
+		if values, err = yaml.Marshal(r.Config); err != nil {
+			return "", fmt.Errorf("encode values: %w", err)
+		}
+	}
+	manifest, err := MaskManifestSecrets(r.Manifest, mask)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Release:   %s/%s\n", r.Namespace, r.Name)
+	fmt.Fprintf(&sb, "Chart:     %s-%s\n", r.Chart.Metadata.Name, r.Chart.Metadata.Version)
+	fmt.Fprintf(&sb, "App:       %s\n", r.Chart.Metadata.AppVersion)
+	fmt.Fprintf(&sb, "Revision:  %d\n", r.Version)
+	fmt.Fprintf(&sb, "Status:    %s\n", r.Info.Status)
+	fmt.Fprintf(&sb, "Deployed:  %s\n", r.Info.LastDeployed.Format(time.RFC3339))
+	fmt.Fprintf(&sb, "\n# Values\n%s", values)
+	fmt.Fprintf(&sb, "\n# Manifest\n%s\n", strings.TrimSpace(manifest))
+	return sb.String(), nil
+}
+
+var manifestSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+
+// MaskManifestSecrets masks data and stringData values of Secret
+// documents in multi-document YAML manifest. Other documents are
+// kept as is.
+func MaskManifestSecrets(manifest string, mask MaskMode) (string, error) {
+	docs := manifestSeparator.Split(manifest, -1)
+	for i, doc := range docs {
+		var obj map[string]any
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return "", fmt.Errorf("parse manifest: %w", err)
+		}
+		if obj["kind"] != "Secret" {
+			continue
+		}
+		maskValues(obj["data"], mask, true)
+		maskValues(obj["stringData"], mask, false)
+		out, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", fmt.Errorf("encode manifest: %w", err)
+		}
+		docs[i] = "\n" + leadingComments(doc) + string(out)
+	}
+	return strings.Join(docs, "---"), nil
+}
+
+// maskValues replaces values of the map with masked ones,
+// base64 decoding them first if encoded.
+func maskValues(m any, mask MaskMode, encoded bool) {
+	values, ok := m.(map[string]any)
+	if !ok {
+		return
+	}
+	for k, v := range values {
+		s := fmt.Sprint(v)
+		data := []byte(s)
+		if encoded {
+			if decoded, err := base64.StdEncoding.DecodeString(s); err == nil {
+				data = decoded
+			}
+		}
+		values[k] = mask.Mask(data)
+	}
+}
+
+// leadingComments returns comment lines at the start of YAML document,
+// such as "# Source:" lines added by Helm.
+func leadingComments(doc string) string {
+	var sb strings.Builder
+	for _, line := range strings.Split(strings.TrimLeft(doc, "\n"), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
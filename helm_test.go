@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+const testHelmManifest = `---
+# Source: app/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  mode: production
+---
+# Source: app/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+data:
+  password: c2VjcmV0MTIz
+stringData:
+  token: plaintext-token
+`
+
+func encodeHelmRelease(t *testing.T, release string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(release)); err != nil {
+		t.Fatalf("failed to compress release: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to compress release: %v", err)
+	}
+	return []byte(base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+func TestDecodeHelmRelease(t *testing.T) {
+	data := encodeHelmRelease(t, `{
+		"name": "app", "namespace": "default", "version": 3,
+		"info": {"status": "deployed", "last_deployed": "2024-03-01T12:00:00Z"},
+		"chart": {"metadata": {"name": "app", "version": "1.2.0", "appVersion": "2.0"}},
+		"config": {"replicas": 2},
+		"manifest": "apiVersion: v1\nkind: ConfigMap\n"
+	}`)
+
+	release, err := DecodeHelmRelease(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if release.Name != "app" || release.Version != 3 || release.Info.Status != "deployed" {
+		t.Errorf("unexpected release: %+v", release)
+	}
+	if release.Chart.Metadata.Version != "1.2.0" {
+		t.Errorf("expected chart version '1.2.0', got '%s'", release.Chart.Metadata.Version)
+	}
+
+	out, err := release.Format(MaskFull)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{"Chart:     app-1.2.0", "Status:    deployed", "replicas: 2", "kind: ConfigMap"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+}
+
+func TestDecodeHelmRelease_Uncompressed(t *testing.T) {
+	data := []byte(base64.StdEncoding.EncodeToString([]byte(`{"name": "app"}`)))
+	release, err := DecodeHelmRelease(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if release.Name != "app" {
+		t.Errorf("expected name 'app', got '%s'", release.Name)
+	}
+
+	if _, err := DecodeHelmRelease([]byte("not base64!")); err == nil {
+		t.Error("expected error for invalid release, got nil")
+	}
+}
+
+func TestMaskManifestSecrets(t *testing.T) {
+	out, err := MaskManifestSecrets(testHelmManifest, MaskFull)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, leaked := range []string{"c2VjcmV0MTIz", "secret123", "plaintext-token"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("expected %q to be masked, got:\n%s", leaked, out)
+		}
+	}
+	for _, expected := range []string{
+		"password: <9 bytes hidden>",
+		"token: <15 bytes hidden>",
+		"# Source: app/templates/secret.yaml",
+		"mode: production",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+}
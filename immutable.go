@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// RecreateSecret replaces immutable secret by deleting it and creating
+// a new one with the same labels, annotations and type but new data.
+// Edits are applied to the new secret, e.g. to change its metadata.
+// The original secret manifest is saved to backupDir before deletion,
+// and the path of backup file is returned.
+func (k *K8SClient) RecreateSecret(ctx context.Context, namespace, name string, data SecretData, backupDir string, edits ...func(*corev1.Secret)) (string, error) {
+	secrets := k.clientset.CoreV1().Secrets(namespace)
+	origin, err := secrets.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("get secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+
+	backup, err := BackupSecret(origin, backupDir, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	replacement := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        origin.Name,
+			Namespace:   origin.Namespace,
+			Labels:      origin.Labels,
+			Annotations: origin.Annotations,
+		},
+		Type:      origin.Type,
+		Immutable: origin.Immutable,
+		Data:      data,
+	}
+	for _, edit := range edits {
+		edit(replacement)
+	}
+
+	var keys []string
+	for _, c := range DiffSecretData(origin.Data, data) {
+		keys = append(keys, c.Key)
+	}
+	k.stamp(ctx, &replacement.ObjectMeta, keys...)
+
+	// UID precondition protects from deleting a secret recreated concurrently
+	err = secrets.Delete(ctx, name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &origin.UID},
+	})
+	if err != nil {
+		return backup, fmt.Errorf("delete secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	if _, err := secrets.Create(ctx, replacement, metav1.CreateOptions{}); err != nil {
+		return backup, fmt.Errorf("create secret '%s' in namespace '%s' (backup: %s): %w",
+			name, namespace, backup, err)
+	}
+	return backup, nil
+}
+
+// droppedManifestAnnotations are not copied to secret manifests: last
+// applied configuration holds secret data in plain base64, and service
+// account UID is populated by the token controller.
+var droppedManifestAnnotations = []string{
+	corev1.LastAppliedConfigAnnotation,
+	corev1.ServiceAccountUIDKey,
+}
+
+// SecretManifest returns copy of secret suitable for `kubectl apply`,
+// without server populated metadata.
+func SecretManifest(secret *corev1.Secret) *corev1.Secret {
+	manifest := secret.DeepCopy()
+	manifest.APIVersion = "v1"
+	manifest.Kind = "Secret"
+	manifest.ObjectMeta = metav1.ObjectMeta{
+		Name:        secret.Name,
+		Namespace:   secret.Namespace,
+		Labels:      secret.Labels,
+		Annotations: manifestAnnotations(secret.Annotations),
+	}
+	return manifest
+}
+
+// manifestAnnotations returns copy of annotations without dropped ones.
+func manifestAnnotations(annotations map[string]string) map[string]string {
+	res := maps.Clone(annotations)
+	for _, k := range droppedManifestAnnotations {
+		delete(res, k)
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
+
+// BackupSecret writes secret manifest suitable for `kubectl apply`
+// to a private file in dir and returns its path.
+func BackupSecret(secret *corev1.Secret, dir string, at time.Time) (string, error) {
+	data, err := yaml.Marshal(SecretManifest(secret))
+	if err != nil {
+		return "", fmt.Errorf("marshal secret backup: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s-%s.yaml", secret.Namespace, secret.Name, at.UTC().Format("20060102T150405Z"))
+	p := filepath.Join(dir, name)
+	if err := WritePrivateFile(p, data); err != nil {
+		return "", fmt.Errorf("write secret backup: %w", err)
+	}
+	return p, nil
+}
+
+// BackupDir returns directory for secret backups.
+func BackupDir() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "backups"), nil
+}
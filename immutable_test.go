@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+)
+
+func newImmutableSecret() *corev1.Secret {
+	immutable := true
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "mysecret",
+			Namespace:       "default",
+			UID:             "uid-1",
+			ResourceVersion: "42",
+			Labels:          map[string]string{"app": "web"},
+			Annotations:     map[string]string{"note": "keep"},
+		},
+		Type:      corev1.SecretTypeBasicAuth,
+		Immutable: &immutable,
+		Data: map[string][]byte{
+			"username": []byte("admin"),
+			"password": []byte("old"),
+		},
+	}
+}
+
+func TestGetSecret_Immutable(t *testing.T) {
+	client := &K8SClient{clientset: fake.NewSimpleClientset(newImmutableSecret())}
+
+	secret, err := client.GetSecret(context.Background(), "default", "mysecret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !secret.Immutable {
+		t.Error("expected secret to be immutable")
+	}
+}
+
+func TestRecreateSecret(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(newImmutableSecret())
+	client := &K8SClient{clientset: fakeClientset}
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	backup, err := client.RecreateSecret(ctx, "default", "mysecret", SecretData{
+		"username": []byte("admin"),
+		"password": []byte("new"),
+	}, dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(backup, dir) {
+		t.Fatalf("expected backup in %s, got %s", dir, backup)
+	}
+	if _, err := os.Stat(backup); err != nil {
+		t.Errorf("expected backup to be kept: %v", err)
+	}
+
+	secret, err := fakeClientset.CoreV1().Secrets("default").Get(ctx, "mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(secret.Data["password"]) != "new" {
+		t.Errorf("expected password='new', got '%s'", string(secret.Data["password"]))
+	}
+	if secret.Labels["app"] != "web" || secret.Annotations["note"] != "keep" {
+		t.Errorf("expected labels and annotations to be preserved, got %v %v", secret.Labels, secret.Annotations)
+	}
+	if secret.Type != corev1.SecretTypeBasicAuth {
+		t.Errorf("expected type %s, got %s", corev1.SecretTypeBasicAuth, secret.Type)
+	}
+	if secret.Immutable == nil || !*secret.Immutable {
+		t.Error("expected recreated secret to stay immutable")
+	}
+}
+
+func TestRecreateSecret_CreateFails(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(newImmutableSecret())
+	fakeClientset.PrependReactor("create", "secrets", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("quota exceeded")
+	})
+	client := &K8SClient{clientset: fakeClientset}
+	dir := t.TempDir()
+
+	backup, err := client.RecreateSecret(context.Background(), "default", "mysecret", SecretData{
+		"password": []byte("new"),
+	}, dir)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.HasPrefix(backup, dir) {
+		t.Fatalf("expected backup in %s, got %s", dir, backup)
+	}
+	if _, err := os.Stat(backup); err != nil {
+		t.Errorf("expected backup to be kept: %v", err)
+	}
+}
+
+func TestBackupSecret(t *testing.T) {
+	dir := t.TempDir()
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	p, err := BackupSecret(newImmutableSecret(), dir, at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(p, "default-mysecret-20240102T030405Z.yaml") {
+		t.Errorf("unexpected backup path: %s", p)
+	}
+
+	fi, err := os.Stat(p)
+	if err != nil {
+		t.Fatalf("failed to stat backup: %v", err)
+	}
+	if mode := fi.Mode().Perm(); mode != 0o600 {
+		t.Errorf("expected permissions 600, got %o", mode)
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatalf("failed to read backup: %v", err)
+	}
+	var restored corev1.Secret
+	if err := yaml.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("failed to parse backup: %v", err)
+	}
+	if restored.Kind != "Secret" || restored.APIVersion != "v1" {
+		t.Errorf("unexpected kind %s/%s", restored.APIVersion, restored.Kind)
+	}
+	if restored.ResourceVersion != "" || restored.UID != "" {
+		t.Error("expected server fields to be stripped from backup")
+	}
+	if string(restored.Data["password"]) != "old" {
+		t.Errorf("expected password='old', got '%s'", string(restored.Data["password"]))
+	}
+}
+
+func TestSecretManifest_DropsAnnotations(t *testing.T) {
+	secret := newImmutableSecret()
+	secret.Annotations[corev1.LastAppliedConfigAnnotation] = `{"data":{"password":"b2xk"}}`
+	secret.Annotations[corev1.ServiceAccountUIDKey] = "sa-uid"
+
+	manifest := SecretManifest(secret)
+	if len(manifest.Annotations) != 1 || manifest.Annotations["note"] != "keep" {
+		t.Errorf("expected only 'note' annotation, got %v", manifest.Annotations)
+	}
+	if _, ok := secret.Annotations[corev1.LastAppliedConfigAnnotation]; !ok {
+		t.Error("expected original secret annotations to be left unchanged")
+	}
+
+	secret.Annotations = map[string]string{corev1.LastAppliedConfigAnnotation: "{}"}
+	if manifest := SecretManifest(secret); manifest.Annotations != nil {
+		t.Errorf("expected no annotations, got %v", manifest.Annotations)
+	}
+}
+
+func TestRecreateSecret_Edits(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(newImmutableSecret())
+	client := &K8SClient{clientset: fakeClientset}
+	ctx := context.Background()
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	_, err := client.RecreateSecret(ctx, "default", "mysecret", SecretData{"password": []byte("new")}, t.TempDir(),
+		func(s *corev1.Secret) { MarkRotated(&s.ObjectMeta, at) })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secret, err := fakeClientset.CoreV1().Secrets("default").Get(ctx, "mysecret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if secret.Annotations[rotatedAtAnnotation] != "2024-01-02T03:04:05Z" || secret.Annotations["note"] != "keep" {
+		t.Errorf("expected rotation annotation to be added, got %v", secret.Annotations)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"path"
+	"path/filepath"
 )
 
 type TmpFile struct {
@@ -71,3 +72,41 @@ func (t *TmpFile) Close() error {
 	}
 	return nil
 }
+
+// ConfigDir returns directory for secctl configuration:
+// $XDG_CONFIG_HOME/secctl or ~/.config/secctl.
+func ConfigDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "secctl"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "secctl"), nil
+}
+
+// StateDir returns directory for local secctl state:
+// $XDG_STATE_HOME/secctl or ~/.local/state/secctl.
+func StateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "secctl"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "state", "secctl"), nil
+}
+
+// WritePrivateFile writes data to file readable only by owner,
+// creating parent directories if needed.
+func WritePrivateFile(p string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return fmt.Errorf("error creating directory: %w", err)
+	}
+	if err := os.WriteFile(p, data, 0o600); err != nil {
+		return fmt.Errorf("error writing file: %w", err)
+	}
+	return nil
+}
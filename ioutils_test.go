@@ -165,3 +165,15 @@ func newTestTmpFile(t *testing.T) *TmpFile {
 	})
 	return tmp
 }
+
+func TestStateDir(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/custom/state")
+
+	dir, err := StateDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dir != "/custom/state/secctl" {
+		t.Errorf("expected /custom/state/secctl, got %s", dir)
+	}
+}
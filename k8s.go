@@ -3,31 +3,87 @@ package main
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
+// listPageSize limits the number of objects fetched per list request.
+const listPageSize = 500
+
+var secretsResource = corev1.SchemeGroupVersion.WithResource("secrets")
+
 type K8SClient struct {
 	clientset kubernetes.Interface
+	// metadata client lists objects without their content
+	metadata metadata.Interface
+	// name and namespace of the current kubeconfig context
+	context   string
+	namespace string
+	// user is the kubeconfig user of the current context
+	user string
+	// author and reason of changes recorded in secret annotations
+	author string
+	reason string
 }
 
 func NewK8SClient(cfgPath string) (*K8SClient, error) {
-	if cfgPath == "" {
-		cfgPath = clientcmd.NewDefaultClientConfigLoadingRules().GetDefaultFilename()
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = cfgPath
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{})
+
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("build client config: %w", err)
 	}
-	config, err := clientcmd.BuildConfigFromFlags("", cfgPath)
+	namespace, _, err := clientConfig.Namespace()
 	if err != nil {
-		return nil, fmt.Errorf("build config from flags: %w", err)
+		return nil, fmt.Errorf("get context namespace: %w", err)
+	}
+	rawConfig, err := clientConfig.RawConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
 	}
 
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, err
 	}
+	metadataClient, err := metadata.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &K8SClient{
+		clientset: clientset,
+		metadata:  metadataClient,
+		context:   rawConfig.CurrentContext,
+		namespace: namespace,
+		user:      kubeconfigUser(rawConfig.Contexts[rawConfig.CurrentContext]),
+	}, nil
+}
+
+func kubeconfigUser(ctx *clientcmdapi.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	return ctx.AuthInfo
+}
 
-	return &K8SClient{clientset: clientset}, nil
+// Context returns the name of the current kubeconfig context.
+func (k *K8SClient) Context() string {
+	return k.context
+}
+
+// Namespace returns the namespace of the current kubeconfig context.
+func (k *K8SClient) Namespace() string {
+	return k.namespace
 }
 
 func (k *K8SClient) ListNamespaces(ctx context.Context) ([]string, error) {
@@ -43,27 +99,75 @@ func (k *K8SClient) ListNamespaces(ctx context.Context) ([]string, error) {
 	return items, nil
 }
 
-func (k *K8SClient) ListSecrets(ctx context.Context, namespace string) ([]string, error) {
-	secrets, err := k.clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+// ListSecrets lists secret names in namespace. Only metadata of secrets
+// is fetched, page by page, so secret values are not transferred.
+func (k *K8SClient) ListSecrets(ctx context.Context, namespace string, filter SecretFilter) ([]string, error) {
+	var items []string
+	err := k.listSecretsMetadata(ctx, namespace, filter, func(secret *metav1.PartialObjectMetadata) {
+		items = append(items, secret.Name)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("list secrets in namespace '%s': %w", namespace, err)
 	}
+	return items, nil
+}
 
-	items := make([]string, len(secrets.Items))
-	for i, secret := range secrets.Items {
-		items[i] = secret.Name
+// ListAllSecrets lists secrets in all namespaces as "namespace/name" items.
+func (k *K8SClient) ListAllSecrets(ctx context.Context, filter SecretFilter) ([]string, error) {
+	var items []string
+	err := k.listSecretsMetadata(ctx, metav1.NamespaceAll, filter, func(secret *metav1.PartialObjectMetadata) {
+		items = append(items, secret.Namespace+"/"+secret.Name)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list secrets in all namespaces: %w", err)
 	}
 	return items, nil
 }
 
+func (k *K8SClient) listSecretsMetadata(ctx context.Context, namespace string, filter SecretFilter,
+	fn func(*metav1.PartialObjectMetadata),
+) error {
+	opts := filter.ListOptions()
+	opts.Limit = listPageSize
+	for {
+		page, err := k.metadata.Resource(secretsResource).Namespace(namespace).List(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for i := range page.Items {
+			fn(&page.Items[i])
+		}
+		if page.Continue == "" {
+			return nil
+		}
+		opts.Continue = page.Continue
+	}
+}
+
 type SecretData map[string][]byte
 
-func (k *K8SClient) GetSecret(ctx context.Context, namespace, name string) (SecretData, error) {
+// Secret is secret data with attributes affecting how it can be edited.
+type Secret struct {
+	Type string
+	Data SecretData
+	// Immutable secrets can't be updated, only deleted and recreated.
+	Immutable bool
+}
+
+func (k *K8SClient) GetSecret(ctx context.Context, namespace, name string) (*Secret, error) {
 	secret, err := k.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("get secret '%s' in namespace '%s': %w", name, namespace, err)
 	}
-	return secret.Data, nil
+	return newSecret(secret), nil
+}
+
+func newSecret(secret *corev1.Secret) *Secret {
+	return &Secret{
+		Type:      string(secret.Type),
+		Data:      secret.Data,
+		Immutable: secret.Immutable != nil && *secret.Immutable,
+	}
 }
 
 func (k *K8SClient) SaveSecret(ctx context.Context, namespace, name, key string, data []byte) error {
@@ -76,6 +180,129 @@ func (k *K8SClient) SaveSecret(ctx context.Context, namespace, name, key string,
 		secret.Data = make(map[string][]byte)
 	}
 	secret.Data[key] = data
+	k.stamp(ctx, &secret.ObjectMeta, key)
+
+	if _, err = k.clientset.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	return nil
+}
+
+// GetSecretManifest returns secret manifest without server populated metadata.
+func (k *K8SClient) GetSecretManifest(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
+	secret, err := k.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	return SecretManifest(secret), nil
+}
+
+// CreateSecret creates secret from manifest.
+func (k *K8SClient) CreateSecret(ctx context.Context, secret *corev1.Secret) error {
+	k.stamp(ctx, &secret.ObjectMeta, slices.Collect(maps.Keys(secret.Data))...)
+	if _, err := k.clientset.CoreV1().Secrets(secret.Namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("create secret '%s' in namespace '%s': %w", secret.Name, secret.Namespace, err)
+	}
+	return nil
+}
+
+// ReplaceSecretData replaces all data of the secret.
+func (k *K8SClient) ReplaceSecretData(ctx context.Context, namespace, name string, data SecretData) error {
+	secret, err := k.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	for _, c := range DiffSecretData(secret.Data, data) {
+		keys = append(keys, c.Key)
+	}
+	secret.Data = data
+	k.stamp(ctx, &secret.ObjectMeta, keys...)
+
+	if _, err = k.clientset.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	return nil
+}
+
+// SaveSecretKeys sets values of several keys of the secret.
+func (k *K8SClient) SaveSecretKeys(ctx context.Context, namespace, name string, values SecretData) error {
+	secret, err := k.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	maps.Copy(secret.Data, values)
+	k.stamp(ctx, &secret.ObjectMeta, slices.Collect(maps.Keys(values))...)
+
+	if _, err = k.clientset.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	return nil
+}
+
+func (k *K8SClient) GetSecretMetadata(ctx context.Context, namespace, name string) (SecretMetadata, error) {
+	secret, err := k.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return SecretMetadata{}, fmt.Errorf("get secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	return SecretMetadata{Labels: secret.Labels, Annotations: secret.Annotations}, nil
+}
+
+func (k *K8SClient) SaveSecretMetadata(ctx context.Context, namespace, name string, meta SecretMetadata) error {
+	secret, err := k.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	secret.Labels = meta.Labels
+	secret.Annotations = meta.Annotations
+	k.stamp(ctx, &secret.ObjectMeta)
+
+	if _, err = k.clientset.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	return nil
+}
+
+func (k *K8SClient) DeleteSecretKey(ctx context.Context, namespace, name, key string) error {
+	secret, err := k.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if _, ok := secret.Data[key]; !ok {
+		return fmt.Errorf("key '%s' not found in secret '%s' in namespace '%s'", key, name, namespace)
+	}
+	delete(secret.Data, key)
+	k.stamp(ctx, &secret.ObjectMeta, key)
+
+	if _, err = k.clientset.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	return nil
+}
+
+func (k *K8SClient) RenameSecretKey(ctx context.Context, namespace, name, oldKey, newKey string) error {
+	secret, err := k.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	data, ok := secret.Data[oldKey]
+	if !ok {
+		return fmt.Errorf("key '%s' not found in secret '%s' in namespace '%s'", oldKey, name, namespace)
+	}
+	if _, ok := secret.Data[newKey]; ok {
+		return fmt.Errorf("key '%s' already exists in secret '%s' in namespace '%s'", newKey, name, namespace)
+	}
+	delete(secret.Data, oldKey)
+	secret.Data[newKey] = data
+	k.stamp(ctx, &secret.ObjectMeta, oldKey, newKey)
 
 	if _, err = k.clientset.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
 		return fmt.Errorf("update secret '%s' in namespace '%s': %w", name, namespace, err)
@@ -2,11 +2,16 @@ package main
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	metadatafake "k8s.io/client-go/metadata/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func TestNewK8SClient_MissingConfig(t *testing.T) {
@@ -17,6 +22,39 @@ func TestNewK8SClient_MissingConfig(t *testing.T) {
 	}
 }
 
+func TestNewK8SClient_ContextNamespace(t *testing.T) {
+	kubeconfig := `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:6443
+users:
+- name: test
+  user:
+    token: test
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+    namespace: team-a
+current-context: test
+`
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(kubeconfig), 0o600); err != nil {
+		t.Fatalf("failed to write kubeconfig: %v", err)
+	}
+
+	client, err := NewK8SClient(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ns := client.Namespace(); ns != "team-a" {
+		t.Errorf("expected namespace 'team-a', got '%s'", ns)
+	}
+}
+
 func TestListNamespaces(t *testing.T) {
 	fakeClientset := fake.NewSimpleClientset(
 		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
@@ -50,7 +88,7 @@ func TestListNamespaces(t *testing.T) {
 }
 
 func TestListSecrets(t *testing.T) {
-	fakeClientset := fake.NewSimpleClientset(
+	secretObjects := []*corev1.Secret{
 		&corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      "secret1",
@@ -69,13 +107,13 @@ func TestListSecrets(t *testing.T) {
 				Namespace: "kube-system",
 			},
 		},
-	)
+	}
 
-	client := &K8SClient{clientset: fakeClientset}
+	client := &K8SClient{metadata: newFakeSecretsMetadata(t, secretObjects...)}
 	ctx := context.Background()
 
 	// List secrets in default namespace
-	secrets, err := client.ListSecrets(ctx, "default")
+	secrets, err := client.ListSecrets(ctx, "default", SecretFilter{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -120,16 +158,16 @@ func TestGetSecret(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if len(secret) != 2 {
-		t.Errorf("expected 2 keys, got %d", len(secret))
+	if len(secret.Data) != 2 {
+		t.Errorf("expected 2 keys, got %d", len(secret.Data))
 	}
 
-	if string(secret["username"]) != "admin" {
-		t.Errorf("expected username='admin', got '%s'", string(secret["username"]))
+	if string(secret.Data["username"]) != "admin" {
+		t.Errorf("expected username='admin', got '%s'", string(secret.Data["username"]))
 	}
 
-	if string(secret["password"]) != "secret123" {
-		t.Errorf("expected password='secret123', got '%s'", string(secret["password"]))
+	if string(secret.Data["password"]) != "secret123" {
+		t.Errorf("expected password='secret123', got '%s'", string(secret.Data["password"]))
 	}
 }
 
@@ -174,8 +212,8 @@ func TestSaveSecret(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if string(secret["key1"]) != "updated_value" {
-		t.Errorf("expected key1='updated_value', got '%s'", string(secret["key1"]))
+	if string(secret.Data["key1"]) != "updated_value" {
+		t.Errorf("expected key1='updated_value', got '%s'", string(secret.Data["key1"]))
 	}
 }
 
@@ -208,12 +246,12 @@ func TestSaveSecret_NewKey(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if len(secret) != 2 {
-		t.Errorf("expected 2 keys, got %d", len(secret))
+	if len(secret.Data) != 2 {
+		t.Errorf("expected 2 keys, got %d", len(secret.Data))
 	}
 
-	if string(secret["key2"]) != "value2" {
-		t.Errorf("expected key2='value2', got '%s'", string(secret["key2"]))
+	if string(secret.Data["key2"]) != "value2" {
+		t.Errorf("expected key2='value2', got '%s'", string(secret.Data["key2"]))
 	}
 }
 
@@ -228,3 +266,290 @@ func TestSaveSecret_NotFound(t *testing.T) {
 		t.Error("expected error for non-existent secret, got nil")
 	}
 }
+
+func TestSaveSecretMetadata(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "mysecret",
+				Namespace:   "default",
+				Labels:      map[string]string{"app": "old"},
+				Annotations: map[string]string{"note": "remove me"},
+			},
+			Data: map[string][]byte{
+				"key1": []byte("value1"),
+			},
+		},
+	)
+
+	client := &K8SClient{clientset: fakeClientset}
+	ctx := context.Background()
+
+	err := client.SaveSecretMetadata(ctx, "default", "mysecret", SecretMetadata{
+		Labels: map[string]string{"app": "new"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	meta, err := client.GetSecretMetadata(ctx, "default", "mysecret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if meta.Labels["app"] != "new" {
+		t.Errorf("expected label app='new', got '%s'", meta.Labels["app"])
+	}
+	if _, ok := meta.Annotations["note"]; ok {
+		t.Errorf("expected annotation 'note' to be removed, got %v", meta.Annotations)
+	}
+	if len(meta.Annotations) != 2 || meta.Annotations[lastModifiedByAnnotation] == "" {
+		t.Errorf("expected only provenance annotations, got %v", meta.Annotations)
+	}
+
+	// Data must be left intact
+	secret, err := client.GetSecret(ctx, "default", "mysecret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(secret.Data["key1"]) != "value1" {
+		t.Errorf("expected key1='value1', got '%s'", string(secret.Data["key1"]))
+	}
+}
+
+func TestGetSecretMetadata_NotFound(t *testing.T) {
+	client := &K8SClient{clientset: fake.NewSimpleClientset()}
+
+	_, err := client.GetSecretMetadata(context.Background(), "default", "nonexistent")
+	if err == nil {
+		t.Error("expected error for non-existent secret, got nil")
+	}
+}
+
+func TestDeleteSecretKey(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mysecret",
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				"key1": []byte("value1"),
+				"key2": []byte("value2"),
+			},
+		},
+	)
+
+	client := &K8SClient{clientset: fakeClientset}
+	ctx := context.Background()
+
+	if err := client.DeleteSecretKey(ctx, "default", "mysecret", "key1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secret, err := client.GetSecret(ctx, "default", "mysecret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := secret.Data["key1"]; ok {
+		t.Error("expected key1 to be deleted")
+	}
+	if len(secret.Data) != 1 {
+		t.Errorf("expected 1 key, got %d", len(secret.Data))
+	}
+
+	if err := client.DeleteSecretKey(ctx, "default", "mysecret", "key1"); err == nil {
+		t.Error("expected error for missing key, got nil")
+	}
+}
+
+func TestRenameSecretKey(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mysecret",
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				"key1": []byte("value1"),
+				"key2": []byte("value2"),
+			},
+		},
+	)
+
+	client := &K8SClient{clientset: fakeClientset}
+	ctx := context.Background()
+
+	if err := client.RenameSecretKey(ctx, "default", "mysecret", "key1", "key3"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secret, err := client.GetSecret(ctx, "default", "mysecret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := secret.Data["key1"]; ok {
+		t.Error("expected key1 to be removed")
+	}
+	if string(secret.Data["key3"]) != "value1" {
+		t.Errorf("expected key3='value1', got '%s'", string(secret.Data["key3"]))
+	}
+
+	if err := client.RenameSecretKey(ctx, "default", "mysecret", "key2", "key3"); err == nil {
+		t.Error("expected error for existing target key, got nil")
+	}
+}
+
+func TestReplaceSecretData(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mysecret",
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				"key1": []byte("value1"),
+				"key2": []byte("value2"),
+			},
+		},
+	)
+
+	client := &K8SClient{clientset: fakeClientset}
+	ctx := context.Background()
+
+	if err := client.ReplaceSecretData(ctx, "default", "mysecret", SecretData{"key3": []byte("value3")}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secret, err := client.GetSecret(ctx, "default", "mysecret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(secret.Data) != 1 || string(secret.Data["key3"]) != "value3" {
+		t.Errorf("expected only key3, got %v", secret.Data)
+	}
+}
+
+func TestGetSecretManifest(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "mysecret",
+				Namespace:       "default",
+				ResourceVersion: "42",
+				UID:             "uid",
+			},
+			Data: map[string][]byte{"key1": []byte("value1")},
+		},
+	)
+
+	client := &K8SClient{clientset: fakeClientset}
+	manifest, err := client.GetSecretManifest(context.Background(), "default", "mysecret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if manifest.Kind != "Secret" || manifest.APIVersion != "v1" {
+		t.Errorf("expected v1 Secret, got %s %s", manifest.APIVersion, manifest.Kind)
+	}
+	if manifest.ResourceVersion != "" || manifest.UID != "" {
+		t.Errorf("expected server metadata to be dropped, got %+v", manifest.ObjectMeta)
+	}
+}
+
+func TestListAllSecrets(t *testing.T) {
+	client := &K8SClient{metadata: newFakeSecretsMetadata(t,
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret1", Namespace: "default"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret2", Namespace: "kube-system"}},
+	)}
+	secrets, err := client.ListAllSecrets(context.Background(), SecretFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedSecrets := map[string]bool{
+		"default/secret1":     true,
+		"kube-system/secret2": true,
+	}
+	if len(secrets) != len(expectedSecrets) {
+		t.Errorf("expected %d secrets, got %d", len(expectedSecrets), len(secrets))
+	}
+	for _, s := range secrets {
+		if !expectedSecrets[s] {
+			t.Errorf("unexpected secret: %s", s)
+		}
+	}
+}
+
+func TestListSecrets_Paginated(t *testing.T) {
+	metadataClient := newFakeSecretsMetadata(t)
+	var requests int
+	// fake metadata client doesn't pass list options to reactors,
+	// so pages are served by the request number
+	metadataClient.PrependReactor("list", "secrets", func(k8stesting.Action) (bool, runtime.Object, error) {
+		requests++
+		list := &metav1.List{}
+		name := "second"
+		if requests == 1 {
+			list.Continue = "next-page"
+			name = "first"
+		}
+		list.Items = []runtime.RawExtension{{Object: secretMetadata(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		})}}
+		return true, list, nil
+	})
+
+	client := &K8SClient{metadata: metadataClient}
+	secrets, err := client.ListSecrets(context.Background(), "default", SecretFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(secrets) != 2 || secrets[0] != "first" || secrets[1] != "second" {
+		t.Errorf("unexpected secrets: %v", secrets)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 list requests, got %d", requests)
+	}
+}
+
+// newFakeSecretsMetadata creates fake metadata client serving
+// metadata of given secrets.
+func newFakeSecretsMetadata(t *testing.T, secrets ...*corev1.Secret) *metadatafake.FakeMetadataClient {
+	t.Helper()
+
+	scheme := metadatafake.NewTestScheme()
+	if err := metav1.AddMetaToScheme(scheme); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	objects := make([]runtime.Object, len(secrets))
+	for i, s := range secrets {
+		objects[i] = secretMetadata(s)
+	}
+	return metadatafake.NewSimpleMetadataClient(scheme, objects...)
+}
+
+func secretMetadata(secret *corev1.Secret) *metav1.PartialObjectMetadata {
+	return &metav1.PartialObjectMetadata{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: secret.ObjectMeta,
+	}
+}
+
+func TestListSecrets_LabelSelector(t *testing.T) {
+	client := &K8SClient{metadata: newFakeSecretsMetadata(t,
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name: "web", Namespace: "default", Labels: map[string]string{"app": "web"},
+		}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name: "db", Namespace: "default", Labels: map[string]string{"app": "db"},
+		}},
+	)}
+
+	secrets, err := client.ListSecrets(context.Background(), "default", SecretFilter{LabelSelector: "app=web"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(secrets) != 1 || secrets[0] != "web" {
+		t.Errorf("expected only 'web' secret, got %v", secrets)
+	}
+}
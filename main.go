@@ -3,8 +3,10 @@ package main
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,9 +22,12 @@ var (
 	builtBy = "unknown"
 )
 
-func main() { //nolint:gocyclo
+func main() {
 	var cfg Config
-	cfg.Parse()
+	if err := cfg.Parse(); err != nil {
+		fatalf("Error parsing configuration: %v", err)
+	}
+	requestTimeout = cfg.Timeout
 
 	if cfg.showVersion {
 		fmt.Printf("k8s-secret-editor version %s ("+
@@ -30,55 +35,38 @@ func main() { //nolint:gocyclo
 			")\n", version, commit, date, builtBy)
 		return
 	}
-
-	k8sClient, err := NewK8SClient(cfg.KubeConfig)
-	if err != nil {
-		fatalf("Error creating Kubernetes client: %v", err)
-	}
-
-	editor, err := NewEditor(cfg.EditorPath)
-	if err != nil {
-		fatalf("Error initializing editor: %v", err)
-	}
-
-	namespaces, err := withTimeoutCtx(func(ctx context.Context) ([]string, error) {
-		return k8sClient.ListNamespaces(ctx)
-	})
-	if err != nil {
-		fatalf("Error loading namespaces: %v", err)
+	if len(cfg.Command) > 0 {
+		runCommand(&cfg)
+		return
 	}
-	selectedNamespace := runPrompt("Select namespace", namespaces)
 
-	secrets, err := withTimeoutCtx(func(ctx context.Context) ([]string, error) {
-		return k8sClient.ListSecrets(ctx, selectedNamespace)
-	})
-	if err != nil {
-		fatalf("Error loading secrets: %v", err)
+	a := newApp(&cfg)
+	if a.selectKind() == kindConfigMap {
+		a.runConfigMap(a.selectConfigMap())
+		return
 	}
-	selectedSecret := runPrompt(fmt.Sprintf("Select secret in '%s'", selectedNamespace), secrets)
+	a.run(a.selectSecret())
+}
 
-	secret, err := withTimeoutCtx(func(ctx context.Context) (SecretData, error) {
-		return k8sClient.GetSecret(ctx, selectedNamespace, selectedSecret)
-	})
-	if err != nil {
-		fatalf("Error loading secret: %v", err)
-	}
-	keys := make([]string, 0, len(secret))
-	for k := range secret {
-		keys = append(keys, k)
-	}
-	selectedKey := runPrompt(fmt.Sprintf("Select key in secret '%s'", selectedSecret), keys)
-	originData, ok := secret[selectedKey]
-	if !ok {
-		fatalf("Key '%s' not found in secret '%s' in namespace '%s'", selectedKey, selectedSecret, selectedNamespace)
+// editData opens origin data in the editor and returns edited data.
+// It returns false if data was not changed.
+func editData(editor *Editor, name string, originData []byte) ([]byte, bool) {
+	editedData := editFile(editor, name, originData)
+	if slices.Equal(originData, editedData) {
+		fmt.Println("No changes detected, exiting.")
+		return nil, false
 	}
+	return editedData, true
+}
 
-	tmpFile, err := NewTmpFile(selectedKey)
+// editFile opens data in the editor and returns edited data.
+func editFile(editor *Editor, name string, data []byte) []byte {
+	tmpFile, err := NewTmpFile(name)
 	if err != nil {
 		fatalf("Error creating temp file: %v", err)
 	}
 	defer tmpFile.Close()
-	if err := tmpFile.Write(originData); err != nil {
+	if err := tmpFile.Write(data); err != nil {
 		fatalf("Error writing secret data to temp file: %v", err)
 	}
 	if err := tmpFile.OpenEditor(editor); err != nil {
@@ -88,49 +76,146 @@ func main() { //nolint:gocyclo
 	if err != nil {
 		fatalf("Error reading edited data from temp file: %v", err)
 	}
+	return editedData
+}
 
-	if slices.Equal(originData, editedData) {
-		fmt.Println("No changes detected, exiting.")
+// printDiff shows changes between origin and edited data.
+// Values are masked unless mask mode is none.
+func printDiff(mask MaskMode, originData, editedData []byte) {
+	if mask != MaskNone {
+		fmt.Printf("- %s\n+ %s\n", mask.Mask(originData), mask.Mask(editedData))
 		return
 	}
-
 	dmp := diffmatchpatch.New()
 	diffs := dmp.DiffMain(string(originData), string(editedData), false)
 	fmt.Println(dmp.DiffPrettyText(diffs))
+}
+
+func confirm(label string) bool {
+	if !promptConfirm(label) {
+		fmt.Println("Save cancelled")
+		return false
+	}
+	return true
+}
 
+func promptConfirm(label string) bool {
 	confirmPrompt := promptui.Prompt{
-		Label:     fmt.Sprintf("Apply changes to secret '%s/%s' key '%s'", selectedNamespace, selectedSecret, selectedKey),
+		Label:     label,
 		IsConfirm: true,
+		Stdin:     promptStdin,
 	}
-	_, err = confirmPrompt.Run()
+	_, err := confirmPrompt.Run()
+	return err == nil
+}
+
+// promptString asks user for a string with default value.
+func promptString(label, def string) string {
+	prompt := promptui.Prompt{Label: label, Default: def, Stdin: promptStdin}
+	result, err := prompt.Run()
 	if err != nil {
-		fmt.Println("Save cancelled")
-		return
+		fatalf("Prompt failed: %v", err)
 	}
+	return result
+}
 
-	_, err = withTimeoutCtx(func(ctx context.Context) (struct{}, error) {
-		err := k8sClient.SaveSecret(ctx, selectedNamespace, selectedSecret, selectedKey, editedData)
-		return struct{}{}, err
-	})
+// promptSecret asks user for a non-empty value without echoing it.
+func promptSecret(label string) string {
+	prompt := promptui.Prompt{
+		Label: label,
+		Mask:  '*',
+		Stdin: promptStdin,
+		Validate: func(input string) error {
+			if input == "" {
+				return fmt.Errorf("value is empty")
+			}
+			return nil
+		},
+	}
+	result, err := prompt.Run()
 	if err != nil {
-		fatalf("Error saving secret '%s' in namespace '%s': %v", selectedSecret, selectedNamespace, err)
+		fatalf("Prompt failed: %v", err)
 	}
+	return result
+}
 
-	fmt.Printf("Secret '%s' in namespace '%s' updated successfully.\n", selectedSecret, selectedNamespace)
+// promptInt asks user for a positive number with default value.
+func promptInt(label string, def int) int {
+	prompt := promptui.Prompt{
+		Label:   label,
+		Default: strconv.Itoa(def),
+		Stdin:   promptStdin,
+		Validate: func(input string) error {
+			if n, err := strconv.Atoi(input); err != nil || n <= 0 {
+				return fmt.Errorf("expected positive number")
+			}
+			return nil
+		},
+	}
+	result, err := prompt.Run()
+	if err != nil {
+		fatalf("Prompt failed: %v", err)
+	}
+	n, _ := strconv.Atoi(result)
+	return n
 }
 
-func runPrompt(title string, items []string) string {
-	slices.Sort(items)
+// typedConfirm asks user to type expected text to confirm a dangerous action.
+func typedConfirm(label, expected string) bool {
+	prompt := promptui.Prompt{
+		Label: fmt.Sprintf("%s (type '%s' to confirm)", label, expected),
+		Stdin: promptStdin,
+	}
+	result, err := prompt.Run()
+	return err == nil && result == expected
+}
+
+// selectAction shows a list of actions in the given order.
+func selectAction(title string, actions []string) string {
 	prompt := promptui.Select{
-		Label:             title,
-		Items:             items,
-		Size:              10,
-		StartInSearchMode: true,
+		Label: title,
+		Items: actions,
+		Stdin: promptStdin,
 		Templates: &promptui.SelectTemplates{
 			Label:    "{{ . }}",
 			Active:   "▸ {{ . | cyan }}",
 			Inactive: "  {{ . }}",
 		},
+	}
+	_, result, err := prompt.Run()
+	if err != nil {
+		fatalf("Prompt failed: %v", err)
+	}
+	return result
+}
+
+// runPrompt asks user to select one of items. Items are sorted
+// alphabetically, except pinned ones which are shown first.
+func runPrompt(title string, items []string, pinned ...string) string {
+	return runDetailsPrompt(title, items, nil, pinned...)
+}
+
+// runDetailsPrompt is runPrompt showing details of the active item
+// rendered by details function.
+func runDetailsPrompt(title string, items []string, details func(string) string, pinned ...string) string {
+	items = pinFirst(items, pinned)
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "▸ {{ . | cyan }}",
+		Inactive: "  {{ . }}",
+	}
+	if details != nil {
+		templates.FuncMap = maps.Clone(promptui.FuncMap)
+		templates.FuncMap["details"] = details
+		templates.Details = "\n{{ details . }}"
+	}
+	prompt := promptui.Select{
+		Label:             title,
+		Items:             items,
+		Size:              10,
+		StartInSearchMode: true,
+		Templates:         templates,
+		Stdin:             promptStdin,
 		Searcher: func(input string, index int) bool {
 			lower := strings.ToLower(input)
 			item := strings.ToLower(items[index])
@@ -147,12 +232,33 @@ func runPrompt(title string, items []string) string {
 	return result
 }
 
+// pinFirst sorts items and moves pinned ones to the top preserving
+// the order of pinned. Pinned values missing in items are ignored.
+func pinFirst(items, pinned []string) []string {
+	rest := slices.Sorted(slices.Values(items))
+	res := make([]string, 0, len(items))
+	for _, p := range pinned {
+		if i := slices.Index(rest, p); i >= 0 {
+			res = append(res, p)
+			rest = slices.Delete(rest, i, i+1)
+		}
+	}
+	return append(res, rest...)
+}
+
+// requestTimeout limits Kubernetes API calls made with withTimeoutCtx.
+var requestTimeout = 30 * time.Second
+
 func withTimeoutCtx[T any](f func(context.Context) (T, error)) (T, error) {
+	return withSpinnerCtx(requestTimeout, f)
+}
+
+func withSpinnerCtx[T any](timeout time.Duration, f func(context.Context) (T, error)) (T, error) {
 	s := spinner.New(spinner.CharSets[22], 100*time.Millisecond)
 	s.Start()
 	defer s.Stop()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 	return f(ctx)
 }
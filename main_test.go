@@ -0,0 +1,22 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPinFirst(t *testing.T) {
+	items := []string{"kube-system", "default", "team-b", "team-a"}
+
+	res := pinFirst(items, []string{"team-b", "missing", "default"})
+	expected := []string{"team-b", "default", "kube-system", "team-a"}
+	if !slices.Equal(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+
+	res = pinFirst(items, nil)
+	expected = []string{"default", "kube-system", "team-a", "team-b"}
+	if !slices.Equal(res, expected) {
+		t.Errorf("expected %v, got %v", expected, res)
+	}
+}
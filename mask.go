@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaskMode defines how secret values are shown in the terminal.
+type MaskMode string
+
+const (
+	// MaskNone shows values as is.
+	MaskNone MaskMode = "none"
+	// MaskPartial shows only a few leading and trailing characters.
+	MaskPartial MaskMode = "partial"
+	// MaskFull hides values completely, only the size is shown.
+	MaskFull MaskMode = "full"
+)
+
+// maskVisibleChars is the number of characters shown at each side
+// of the value in partial mode.
+const maskVisibleChars = 2
+
+func (m MaskMode) String() string {
+	return string(m)
+}
+
+// Set implements flag.Value.
+func (m *MaskMode) Set(s string) error {
+	mode := MaskMode(s)
+	if err := mode.Validate(); err != nil {
+		return err
+	}
+	*m = mode
+	return nil
+}
+
+// Validate checks that mask mode is known.
+func (m MaskMode) Validate() error {
+	switch m {
+	case MaskNone, MaskPartial, MaskFull:
+		return nil
+	}
+	return fmt.Errorf("unknown mask mode '%s', expected one of: %s, %s, %s", m, MaskNone, MaskPartial, MaskFull)
+}
+
+// Mask renders value according to mask mode.
+func (m MaskMode) Mask(data []byte) string {
+	switch m {
+	case MaskNone:
+		return string(data)
+	case MaskPartial:
+		if utf8.Valid(data) {
+			runes := []rune(string(data))
+			if len(runes) > maskVisibleChars*4 && !strings.ContainsRune(string(data), '\n') {
+				return fmt.Sprintf("%s****%s (%d bytes)", string(runes[:maskVisibleChars]),
+					string(runes[len(runes)-maskVisibleChars:]), len(data))
+			}
+		}
+	}
+	return fmt.Sprintf("<%d bytes hidden>", len(data))
+}
+
+// PreviewMask returns mask mode for previews and summaries, which never
+// show values in plain text: none mode falls back to partial.
+func PreviewMask(m MaskMode) MaskMode {
+	if m == MaskNone {
+		return MaskPartial
+	}
+	return m
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestMaskMode_Mask(t *testing.T) {
+	tests := []struct {
+		mode     MaskMode
+		data     string
+		expected string
+	}{
+		{MaskNone, "secret123", "secret123"},
+		{MaskFull, "secret123", "<9 bytes hidden>"},
+		{MaskPartial, "secret123", "se****23 (9 bytes)"},
+		{MaskPartial, "short", "<5 bytes hidden>"},
+		{MaskPartial, "multi\nline\nvalue", "<16 bytes hidden>"},
+		{MaskPartial, "\xff\xfe\xfd\xfc\xfb\xfa\xf9\xf8\xf7", "<9 bytes hidden>"},
+	}
+	for _, tt := range tests {
+		if res := tt.mode.Mask([]byte(tt.data)); res != tt.expected {
+			t.Errorf("%s mask of %q: expected %q, got %q", tt.mode, tt.data, tt.expected, res)
+		}
+	}
+}
+
+func TestMaskMode_Set(t *testing.T) {
+	var m MaskMode
+	if err := m.Set("partial"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m != MaskPartial {
+		t.Errorf("expected 'partial', got '%s'", m)
+	}
+	if err := m.Set("unknown"); err == nil {
+		t.Error("expected error for unknown mode, got nil")
+	}
+}
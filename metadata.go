@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metavalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+// SecretMetadata is the editable part of secret object metadata.
+type SecretMetadata struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// MarshalYAML renders metadata as YAML document for the editor.
+func (m SecretMetadata) MarshalYAML() ([]byte, error) {
+	// keep empty maps in output to show users where to add new entries
+	if m.Labels == nil {
+		m.Labels = map[string]string{}
+	}
+	if m.Annotations == nil {
+		m.Annotations = map[string]string{}
+	}
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("marshal metadata: %w", err)
+	}
+	return data, nil
+}
+
+// ParseSecretMetadata parses YAML metadata document and validates
+// label and annotation syntax.
+func ParseSecretMetadata(data []byte) (SecretMetadata, error) {
+	var m SecretMetadata
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return SecretMetadata{}, fmt.Errorf("parse metadata: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return SecretMetadata{}, err
+	}
+	return m, nil
+}
+
+// Validate checks labels and annotations using Kubernetes API validation rules.
+func (m SecretMetadata) Validate() error {
+	errs := metavalidation.ValidateLabels(m.Labels, field.NewPath("labels"))
+	errs = append(errs, apivalidation.ValidateAnnotations(m.Annotations, field.NewPath("annotations"))...)
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid metadata: %w", errs.ToAggregate())
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSecretMetadata_RoundTrip(t *testing.T) {
+	meta := SecretMetadata{
+		Labels:      map[string]string{"app": "web"},
+		Annotations: map[string]string{"reloader.stakater.com/match": "true"},
+	}
+
+	data, err := meta.MarshalYAML()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	parsed, err := ParseSecretMetadata(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed.Labels["app"] != "web" {
+		t.Errorf("expected label app='web', got '%s'", parsed.Labels["app"])
+	}
+	if parsed.Annotations["reloader.stakater.com/match"] != "true" {
+		t.Errorf("expected annotation value 'true', got '%s'", parsed.Annotations["reloader.stakater.com/match"])
+	}
+}
+
+func TestSecretMetadata_MarshalEmpty(t *testing.T) {
+	data, err := SecretMetadata{}.MarshalYAML()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "annotations: {}\nlabels: {}\n"
+	if string(data) != expected {
+		t.Errorf("expected %q, got %q", expected, string(data))
+	}
+}
+
+func TestParseSecretMetadata_InvalidLabelKey(t *testing.T) {
+	_, err := ParseSecretMetadata([]byte("labels:\n  \"bad key!\": value\n"))
+	if err == nil {
+		t.Error("expected error for invalid label key, got nil")
+	}
+}
+
+func TestParseSecretMetadata_InvalidLabelValue(t *testing.T) {
+	_, err := ParseSecretMetadata([]byte("labels:\n  app: \"not a valid value\"\n"))
+	if err == nil {
+		t.Error("expected error for invalid label value, got nil")
+	}
+}
+
+func TestParseSecretMetadata_UnknownField(t *testing.T) {
+	_, err := ParseSecretMetadata([]byte("lables:\n  app: web\n"))
+	if err == nil {
+		t.Error("expected error for unknown field, got nil")
+	}
+}
+
+func TestParseSecretMetadata_InvalidYAML(t *testing.T) {
+	_, err := ParseSecretMetadata([]byte("labels: [\n"))
+	if err == nil {
+		t.Error("expected error for invalid YAML, got nil")
+	}
+}
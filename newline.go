@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+)
+
+// LineEndingPolicy defines what to do when editing changes
+// trailing newline or line endings of a value.
+type LineEndingPolicy string
+
+const (
+	// LineEndingAsk asks user whether to keep the original style.
+	LineEndingAsk LineEndingPolicy = "ask"
+	// LineEndingKeep restores the original style without asking.
+	LineEndingKeep LineEndingPolicy = "keep"
+	// LineEndingIgnore saves edited value as is.
+	LineEndingIgnore LineEndingPolicy = "ignore"
+)
+
+// Validate checks that line ending policy is known.
+func (p LineEndingPolicy) Validate() error {
+	switch p {
+	case LineEndingAsk, LineEndingKeep, LineEndingIgnore:
+		return nil
+	}
+	return fmt.Errorf("unknown line ending policy '%s', expected one of: %s, %s, %s",
+		p, LineEndingAsk, LineEndingKeep, LineEndingIgnore)
+}
+
+// LineEndingRule sets line ending policy for keys matching the pattern.
+type LineEndingRule struct {
+	// Key is a glob pattern of key names, e.g. "*.pem".
+	Key    string           `json:"key"`
+	Policy LineEndingPolicy `json:"policy"`
+}
+
+// Validate checks rule pattern and policy.
+func (r LineEndingRule) Validate() error {
+	if _, err := path.Match(r.Key, ""); err != nil {
+		return fmt.Errorf("invalid key pattern '%s': %w", r.Key, err)
+	}
+	return r.Policy.Validate()
+}
+
+// MatchLineEndingPolicy returns policy of the first rule matching the key,
+// or LineEndingAsk if none matches.
+func MatchLineEndingPolicy(rules []LineEndingRule, key string) LineEndingPolicy {
+	for _, r := range rules {
+		if ok, _ := path.Match(r.Key, key); ok {
+			return r.Policy
+		}
+	}
+	return LineEndingAsk
+}
+
+// LineStyle is a trailing newline and line endings style of a value.
+type LineStyle struct {
+	TrailingNewline bool
+	CRLF            bool
+}
+
+// DetectLineStyle returns line style of data.
+func DetectLineStyle(data []byte) LineStyle {
+	return LineStyle{
+		TrailingNewline: bytes.HasSuffix(data, []byte("\n")),
+		CRLF:            bytes.Contains(data, []byte("\r\n")),
+	}
+}
+
+func (s LineStyle) String() string {
+	endings := "LF"
+	if s.CRLF {
+		endings = "CRLF"
+	}
+	if s.TrailingNewline {
+		return endings + " with trailing newline"
+	}
+	return endings + " without trailing newline"
+}
+
+// Apply converts line endings of data and adds or removes
+// a single trailing newline to match the style.
+func (s LineStyle) Apply(data []byte) []byte {
+	res := bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	hasTrailing := bytes.HasSuffix(res, []byte("\n"))
+	switch {
+	case s.TrailingNewline && !hasTrailing:
+		res = append(res, '\n')
+	case !s.TrailingNewline && hasTrailing:
+		res = res[:len(res)-1]
+	}
+	if s.CRLF {
+		res = bytes.ReplaceAll(res, []byte("\n"), []byte("\r\n"))
+	}
+	return res
+}
@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestDetectLineStyle(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected LineStyle
+	}{
+		{"token", LineStyle{}},
+		{"token\n", LineStyle{TrailingNewline: true}},
+		{"a\r\nb", LineStyle{CRLF: true}},
+		{"a\r\nb\r\n", LineStyle{TrailingNewline: true, CRLF: true}},
+	}
+	for _, tt := range tests {
+		if res := DetectLineStyle([]byte(tt.data)); res != tt.expected {
+			t.Errorf("%q: expected %+v, got %+v", tt.data, tt.expected, res)
+		}
+	}
+}
+
+func TestLineStyle_Apply(t *testing.T) {
+	tests := []struct {
+		style    LineStyle
+		data     string
+		expected string
+	}{
+		{LineStyle{}, "token\n", "token"},
+		{LineStyle{}, "token\r\n", "token"},
+		{LineStyle{TrailingNewline: true}, "a\nb", "a\nb\n"},
+		{LineStyle{CRLF: true}, "a\nb\n", "a\r\nb"},
+		{LineStyle{TrailingNewline: true, CRLF: true}, "a\nb", "a\r\nb\r\n"},
+	}
+	for _, tt := range tests {
+		if res := tt.style.Apply([]byte(tt.data)); string(res) != tt.expected {
+			t.Errorf("%+v of %q: expected %q, got %q", tt.style, tt.data, tt.expected, res)
+		}
+	}
+}
+
+func TestMatchLineEndingPolicy(t *testing.T) {
+	rules := []LineEndingRule{
+		{Key: "*.pem", Policy: LineEndingIgnore},
+		{Key: "*token*", Policy: LineEndingKeep},
+	}
+	tests := map[string]LineEndingPolicy{
+		"tls.pem":   LineEndingIgnore,
+		"api-token": LineEndingKeep,
+		"password":  LineEndingAsk,
+	}
+	for key, expected := range tests {
+		if res := MatchLineEndingPolicy(rules, key); res != expected {
+			t.Errorf("%s: expected '%s', got '%s'", key, expected, res)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// SecretSummary describes a secret without exposing its values.
+type SecretSummary struct {
+	Type    string
+	Keys    int
+	Created time.Time
+	// Owner is "Kind/Name" of the controlling owner, empty if none.
+	Owner string
+}
+
+// tableAccept requests server-side table view, the one kubectl get
+// prints. Its columns include type and number of keys, and the row
+// object carries only metadata, so secret values are not transferred.
+const tableAccept = "application/json;as=Table;v=v1;g=meta.k8s.io"
+
+// GetSecretSummary summarizes secret for preview.
+func (k *K8SClient) GetSecretSummary(ctx context.Context, namespace, name string) (SecretSummary, error) {
+	raw, err := k.clientset.CoreV1().RESTClient().Get().
+		Namespace(namespace).Resource("secrets").Name(name).
+		SetHeader("Accept", tableAccept).
+		Param("includeObject", string(metav1.IncludeMetadata)).
+		DoRaw(ctx)
+	if err != nil {
+		return SecretSummary{}, fmt.Errorf("get secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	var table metav1.Table
+	if err := json.Unmarshal(raw, &table); err != nil {
+		return SecretSummary{}, fmt.Errorf("decode secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	if len(table.Rows) != 1 {
+		return SecretSummary{}, fmt.Errorf("secret '%s' in namespace '%s': expected 1 row, got %d", name, namespace, len(table.Rows))
+	}
+	row := table.Rows[0]
+
+	var summary SecretSummary
+	for i, col := range table.ColumnDefinitions {
+		if i >= len(row.Cells) {
+			break
+		}
+		switch col.Name {
+		case "Type":
+			summary.Type, _ = row.Cells[i].(string)
+		case "Data":
+			// JSON numbers are decoded as float64
+			if n, ok := row.Cells[i].(float64); ok {
+				summary.Keys = int(n)
+			}
+		}
+	}
+	var meta metav1.PartialObjectMetadata
+	if err := json.Unmarshal(row.Object.Raw, &meta); err != nil {
+		return SecretSummary{}, fmt.Errorf("decode metadata of secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	summary.Created = meta.CreationTimestamp.Time
+	if owner := metav1.GetControllerOf(&meta); owner != nil {
+		summary.Owner = owner.Kind + "/" + owner.Name
+	} else if len(meta.OwnerReferences) > 0 {
+		summary.Owner = meta.OwnerReferences[0].Kind + "/" + meta.OwnerReferences[0].Name
+	}
+	return summary, nil
+}
+
+// Format renders summary as prompt details.
+func (s SecretSummary) Format(now time.Time) string {
+	owner := s.Owner
+	if owner == "" {
+		owner = "<none>"
+	}
+	return fmt.Sprintf("Type:  %s\nKeys:  %d\nAge:   %s\nOwner: %s",
+		s.Type, s.Keys, duration.HumanDuration(now.Sub(s.Created)), owner)
+}
+
+// FormatKeyDetails renders key value summary with masked preview.
+func FormatKeyDetails(data []byte, mask MaskMode) string {
+	lines := 0
+	if len(data) > 0 {
+		lines = bytes.Count(data, []byte("\n")) + 1
+		if data[len(data)-1] == '\n' {
+			lines--
+		}
+	}
+	return fmt.Sprintf("Size:    %d bytes\nFormat:  %s\nLines:   %d\nPreview: %s",
+		len(data), DetectFormat(data), lines, PreviewMask(mask).Mask(data))
+}
+
+// DetectFormat guesses the format of secret value.
+func DetectFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case len(trimmed) == 0:
+		return "empty"
+	case !utf8.Valid(data):
+		return "binary"
+	}
+
+	if block, _ := pem.Decode(trimmed); block != nil {
+		if block.Type != "CERTIFICATE" {
+			return fmt.Sprintf("PEM (%s)", block.Type)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return "certificate (invalid)"
+		}
+		return fmt.Sprintf("certificate (%s, expires %s)",
+			cert.Subject.String(), cert.NotAfter.UTC().Format(time.DateOnly))
+	}
+	if isJWT(string(trimmed)) {
+		return "JWT"
+	}
+	if json.Valid(trimmed) {
+		return "JSON"
+	}
+	if _, ok := DecodeBase64Layer(trimmed); ok {
+		return "base64 (encoded twice)"
+	}
+	return "text"
+}
+
+func isJWT(s string) bool {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return false
+	}
+	var h struct {
+		Alg string `json:"alg"`
+	}
+	return json.Unmarshal(header, &h) == nil && h.Alg != ""
+}
+
+func formatSize(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := unit, 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Details of prompt items are loaded in background: prompt renders
+// details of the active item on every cursor move, and a slow API server
+// must not freeze it. Only items the cursor stays on for detailsDelay
+// are loaded, so secrets passed over are not fetched.
+const (
+	detailsDelay   = 300 * time.Millisecond
+	detailsTimeout = 3 * time.Second
+	detailsLoading = "Loading details..."
+)
+
+// asyncDetails returns details function which shows a placeholder until
+// details of the item are loaded by fn, then memoizes them. The prompt
+// renders only on input, so refresh is called once details are loaded
+// to show them.
+func asyncDetails(delay time.Duration, fn func(context.Context, string) string, refresh func()) func(string) string {
+	var mux sync.Mutex
+	cache := make(map[string]string)
+	pending := make(map[string]bool)
+	var active string
+	load := func(item string) {
+		time.Sleep(delay)
+		mux.Lock()
+		if active != item {
+			delete(pending, item)
+			mux.Unlock()
+			return
+		}
+		mux.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), detailsTimeout)
+		defer cancel()
+		d := fn(ctx, item)
+		mux.Lock()
+		cache[item] = d
+		delete(pending, item)
+		mux.Unlock()
+		refresh()
+	}
+	return func(item string) string {
+		mux.Lock()
+		defer mux.Unlock()
+		active = item
+		if d, ok := cache[item]; ok {
+			return d
+		}
+		if !pending[item] {
+			pending[item] = true
+			go load(item)
+		}
+		return detailsLoading
+	}
+}
+
+// promptStdin is stdin of all prompts. It lets background loaders make
+// the active prompt render again.
+var promptStdin = newRefreshableInput(os.Stdin)
+
+const ctrlG = 7
+
+// refreshableInput reads from in and injects Ctrl-G when refreshed.
+// Readline passes Ctrl-G to the prompt without changing the input, and
+// the prompt renders again.
+type refreshableInput struct {
+	in      io.Reader
+	once    sync.Once
+	chunks  chan []byte
+	refresh chan struct{}
+	buf     []byte
+}
+
+func newRefreshableInput(in io.Reader) *refreshableInput {
+	return &refreshableInput{
+		in:      in,
+		chunks:  make(chan []byte),
+		refresh: make(chan struct{}, 1),
+	}
+}
+
+// Read returns input read in background, so a refresh does not wait for
+// the user to type.
+func (r *refreshableInput) Read(p []byte) (int, error) {
+	r.once.Do(func() { go r.readLoop() })
+	if len(r.buf) == 0 {
+		select {
+		case chunk, ok := <-r.chunks:
+			if !ok {
+				return 0, io.EOF
+			}
+			r.buf = chunk
+		case <-r.refresh:
+			p[0] = ctrlG
+			return 1, nil
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *refreshableInput) readLoop() {
+	defer close(r.chunks)
+	for {
+		buf := make([]byte, 1024)
+		n, err := r.in.Read(buf)
+		if n > 0 {
+			r.chunks <- buf[:n]
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Refresh makes the prompt reading input render again. Refreshes
+// requested before the prompt reads are merged into one.
+func (r *refreshableInput) Refresh() {
+	select {
+	case r.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// Close keeps stdin open for the next prompt.
+func (r *refreshableInput) Close() error {
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	authv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Annotations recording the last change made with secctl.
+const (
+	lastModifiedByAnnotation     = "secctl.io/last-modified-by"
+	lastModifiedAtAnnotation     = "secctl.io/last-modified-at"
+	lastModifiedKeysAnnotation   = "secctl.io/last-modified-keys"
+	lastModifiedReasonAnnotation = "secctl.io/last-modified-reason"
+)
+
+// Provenance describes who changed a secret, when, which keys and why.
+type Provenance struct {
+	By     string
+	At     time.Time
+	Keys   []string
+	Reason string
+}
+
+// Stamp records provenance in object annotations. Keys and reason
+// annotations of a previous change are removed if not set.
+func (p Provenance) Stamp(meta *metav1.ObjectMeta) {
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string)
+	}
+	meta.Annotations[lastModifiedByAnnotation] = p.By
+	meta.Annotations[lastModifiedAtAnnotation] = p.At.UTC().Format(time.RFC3339)
+
+	keys := slices.Clone(p.Keys)
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+	setOrDelete(meta.Annotations, lastModifiedKeysAnnotation, strings.Join(keys, ","))
+	setOrDelete(meta.Annotations, lastModifiedReasonAnnotation, p.Reason)
+}
+
+func setOrDelete(m map[string]string, key, value string) {
+	if value == "" {
+		delete(m, key)
+		return
+	}
+	m[key] = value
+}
+
+// SetReason sets reason recorded with the following writes.
+func (k *K8SClient) SetReason(reason string) {
+	k.reason = reason
+}
+
+// Author returns the name of the user making changes: the username
+// reported by the API server, or the kubeconfig user if the server
+// doesn't support self subject reviews.
+func (k *K8SClient) Author(ctx context.Context) string {
+	if k.author != "" {
+		return k.author
+	}
+	review, err := k.clientset.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authv1.SelfSubjectReview{}, metav1.CreateOptions{})
+	switch {
+	case err == nil && review.Status.UserInfo.Username != "":
+		k.author = review.Status.UserInfo.Username
+	case k.user != "":
+		k.author = k.user
+	default:
+		k.author = "unknown"
+	}
+	return k.author
+}
+
+// stamp records provenance of the change of keys in secret metadata.
+func (k *K8SClient) stamp(ctx context.Context, meta *metav1.ObjectMeta, keys ...string) {
+	Provenance{By: k.Author(ctx), At: time.Now(), Keys: keys, Reason: k.reason}.Stamp(meta)
+}
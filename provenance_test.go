@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	authv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestProvenance_Stamp(t *testing.T) {
+	meta := metav1.ObjectMeta{Annotations: map[string]string{
+		"note":                       "keep",
+		lastModifiedReasonAnnotation: "old reason",
+	}}
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	Provenance{By: "alice", At: at, Keys: []string{"b", "a", "b"}}.Stamp(&meta)
+	expected := map[string]string{
+		"note":                     "keep",
+		lastModifiedByAnnotation:   "alice",
+		lastModifiedAtAnnotation:   "2024-03-01T12:00:00Z",
+		lastModifiedKeysAnnotation: "a,b",
+	}
+	if len(meta.Annotations) != len(expected) {
+		t.Errorf("expected %v, got %v", expected, meta.Annotations)
+	}
+	for k, v := range expected {
+		if meta.Annotations[k] != v {
+			t.Errorf("expected %s='%s', got '%s'", k, v, meta.Annotations[k])
+		}
+	}
+
+	Provenance{By: "bob", At: at, Reason: "incident 42"}.Stamp(&meta)
+	if meta.Annotations[lastModifiedReasonAnnotation] != "incident 42" {
+		t.Errorf("expected reason 'incident 42', got '%s'", meta.Annotations[lastModifiedReasonAnnotation])
+	}
+	if _, ok := meta.Annotations[lastModifiedKeysAnnotation]; ok {
+		t.Error("expected keys annotation to be removed")
+	}
+}
+
+func TestSaveSecret_Provenance(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("old")},
+	})
+	fakeClientset.PrependReactor("create", "selfsubjectreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &authv1.SelfSubjectReview{Status: authv1.SelfSubjectReviewStatus{
+			UserInfo: authv1.UserInfo{Username: "alice@example.com"},
+		}}, nil
+	})
+	client := &K8SClient{clientset: fakeClientset, user: "kube-admin"}
+	client.SetReason("rotate leaked password")
+	ctx := context.Background()
+
+	if err := client.SaveSecret(ctx, "default", "db", "password", []byte("new")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secret, err := fakeClientset.CoreV1().Secrets("default").Get(ctx, "db", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := secret.Annotations[lastModifiedByAnnotation]; v != "alice@example.com" {
+		t.Errorf("expected author 'alice@example.com', got '%s'", v)
+	}
+	if v := secret.Annotations[lastModifiedKeysAnnotation]; v != "password" {
+		t.Errorf("expected keys 'password', got '%s'", v)
+	}
+	if v := secret.Annotations[lastModifiedReasonAnnotation]; v != "rotate leaked password" {
+		t.Errorf("expected reason 'rotate leaked password', got '%s'", v)
+	}
+}
+
+func TestAuthor_KubeconfigUser(t *testing.T) {
+	client := &K8SClient{clientset: fake.NewSimpleClientset(), user: "kube-admin"}
+	if author := client.Author(context.Background()); author != "kube-admin" {
+		t.Errorf("expected author 'kube-admin', got '%s'", author)
+	}
+}
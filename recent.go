@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// maxRecentItems is the number of recent items kept per kubeconfig context.
+const maxRecentItems = 20
+
+// RecentItem is a recently used secret key.
+type RecentItem struct {
+	Namespace string    `json:"namespace"`
+	Secret    string    `json:"secret"`
+	Key       string    `json:"key,omitempty"`
+	UsedAt    time.Time `json:"usedAt"`
+}
+
+// Recent is the local state of recently used items per kubeconfig context.
+// Items are ordered from the most recent one.
+type Recent struct {
+	path     string
+	Contexts map[string][]RecentItem `json:"contexts"`
+}
+
+// RecentPath returns path of recent items state file.
+func RecentPath() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "recent.json"), nil
+}
+
+// LoadRecent reads recent items from file. Missing file results in empty state.
+func LoadRecent(p string) (*Recent, error) {
+	r := &Recent{path: p, Contexts: make(map[string][]RecentItem)}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read recent items: %w", err)
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("parse recent items '%s': %w", p, err)
+	}
+	if r.Contexts == nil {
+		r.Contexts = make(map[string][]RecentItem)
+	}
+	return r, nil
+}
+
+// Add moves item to the top of recent items of context.
+func (r *Recent) Add(context string, item RecentItem) {
+	items := slices.DeleteFunc(r.Contexts[context], func(i RecentItem) bool {
+		return i.Namespace == item.Namespace && i.Secret == item.Secret && i.Key == item.Key
+	})
+	items = append([]RecentItem{item}, items...)
+	if len(items) > maxRecentItems {
+		items = items[:maxRecentItems]
+	}
+	r.Contexts[context] = items
+}
+
+// Save writes recent items to file.
+func (r *Recent) Save() error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal recent items: %w", err)
+	}
+	if err := WritePrivateFile(r.path, data); err != nil {
+		return fmt.Errorf("write recent items: %w", err)
+	}
+	return nil
+}
+
+// Namespaces returns recently used namespaces of context.
+func (r *Recent) Namespaces(context string) []string {
+	return r.collect(context, func(i RecentItem) (string, bool) {
+		return i.Namespace, true
+	})
+}
+
+// Secrets returns recently used secrets of context as "namespace/name" items.
+func (r *Recent) Secrets(context string) []string {
+	return r.collect(context, func(i RecentItem) (string, bool) {
+		return i.Namespace + "/" + i.Secret, true
+	})
+}
+
+// SecretsIn returns names of recently used secrets in namespace.
+func (r *Recent) SecretsIn(context, namespace string) []string {
+	return r.collect(context, func(i RecentItem) (string, bool) {
+		return i.Secret, i.Namespace == namespace
+	})
+}
+
+// Keys returns recently used keys of secret.
+func (r *Recent) Keys(context, namespace, secret string) []string {
+	return r.collect(context, func(i RecentItem) (string, bool) {
+		return i.Key, i.Namespace == namespace && i.Secret == secret && i.Key != ""
+	})
+}
+
+// collect returns unique values extracted from recent items in order.
+func (r *Recent) collect(context string, value func(RecentItem) (string, bool)) []string {
+	var res []string
+	for _, item := range r.Contexts[context] {
+		if v, ok := value(item); ok && !slices.Contains(res, v) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRecent_AddAndQuery(t *testing.T) {
+	r, err := LoadRecent(filepath.Join(t.TempDir(), "recent.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r.Add("dev", RecentItem{Namespace: "team-a", Secret: "db", Key: "password"})
+	r.Add("dev", RecentItem{Namespace: "team-b", Secret: "api", Key: "token"})
+	r.Add("dev", RecentItem{Namespace: "team-a", Secret: "db", Key: "username"})
+	r.Add("dev", RecentItem{Namespace: "team-a", Secret: "db", Key: "password"})
+	r.Add("prod", RecentItem{Namespace: "prod-ns", Secret: "db", Key: "password"})
+
+	if ns := r.Namespaces("dev"); !slices.Equal(ns, []string{"team-a", "team-b"}) {
+		t.Errorf("unexpected namespaces: %v", ns)
+	}
+	if secrets := r.Secrets("dev"); !slices.Equal(secrets, []string{"team-a/db", "team-b/api"}) {
+		t.Errorf("unexpected secrets: %v", secrets)
+	}
+	if secrets := r.SecretsIn("dev", "team-b"); !slices.Equal(secrets, []string{"api"}) {
+		t.Errorf("unexpected secrets in team-b: %v", secrets)
+	}
+	if keys := r.Keys("dev", "team-a", "db"); !slices.Equal(keys, []string{"password", "username"}) {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+	if ns := r.Namespaces("prod"); !slices.Equal(ns, []string{"prod-ns"}) {
+		t.Errorf("unexpected prod namespaces: %v", ns)
+	}
+}
+
+func TestRecent_Limit(t *testing.T) {
+	r, err := LoadRecent(filepath.Join(t.TempDir(), "recent.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := range maxRecentItems + 5 {
+		r.Add("dev", RecentItem{Namespace: "default", Secret: fmt.Sprintf("secret-%d", i)})
+	}
+	if n := len(r.Contexts["dev"]); n != maxRecentItems {
+		t.Errorf("expected %d items, got %d", maxRecentItems, n)
+	}
+	expected := fmt.Sprintf("secret-%d", maxRecentItems+4)
+	if first := r.Contexts["dev"][0].Secret; first != expected {
+		t.Errorf("expected most recent item '%s', got '%s'", expected, first)
+	}
+}
+
+func TestRecent_SaveAndLoad(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "state", "recent.json")
+	r, err := LoadRecent(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r.Add("dev", RecentItem{Namespace: "default", Secret: "db", Key: "password"})
+	if err := r.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := LoadRecent(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if keys := loaded.Keys("dev", "default", "db"); !slices.Equal(keys, []string{"password"}) {
+		t.Errorf("unexpected keys after reload: %v", keys)
+	}
+}
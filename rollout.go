@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// restartedAtAnnotation is the pod template annotation used by
+// `kubectl rollout restart`.
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+const rolloutPollInterval = 2 * time.Second
+
+var errRolloutNotSupported = errors.New("rollout is not supported")
+
+// IsRestartable reports whether consumer kind supports rollout restart.
+func (c Consumer) IsRestartable() bool {
+	switch c.Kind {
+	case "Deployment", "StatefulSet", "DaemonSet":
+		return true
+	}
+	return false
+}
+
+// RestartableConsumers filters consumers which can be restarted.
+func RestartableConsumers(consumers []Consumer) []Consumer {
+	var res []Consumer
+	for _, c := range consumers {
+		if c.IsRestartable() {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// RestartWorkload triggers rollout restart the same way kubectl does:
+// by patching pod template with restartedAt annotation.
+func (k *K8SClient) RestartWorkload(ctx context.Context, namespace string, c Consumer, at time.Time) error {
+	patch := fmt.Appendf(nil, `{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`,
+		restartedAtAnnotation, at.Format(time.RFC3339))
+
+	apps := k.clientset.AppsV1()
+	var err error
+	switch c.Kind {
+	case "Deployment":
+		_, err = apps.Deployments(namespace).Patch(ctx, c.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	case "StatefulSet":
+		_, err = apps.StatefulSets(namespace).Patch(ctx, c.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	case "DaemonSet":
+		_, err = apps.DaemonSets(namespace).Patch(ctx, c.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	default:
+		return fmt.Errorf("restart %s '%s': %w", c.Kind, c.Name, errRolloutNotSupported)
+	}
+	if err != nil {
+		return fmt.Errorf("restart %s '%s' in namespace '%s': %w", c.Kind, c.Name, namespace, err)
+	}
+	return nil
+}
+
+// RolloutStatus returns rollout status message and true if rollout is complete.
+// The logic follows `kubectl rollout status`.
+func (k *K8SClient) RolloutStatus(ctx context.Context, namespace string, c Consumer) (string, bool, error) {
+	apps := k.clientset.AppsV1()
+	switch c.Kind {
+	case "Deployment":
+		d, err := apps.Deployments(namespace).Get(ctx, c.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", false, fmt.Errorf("get deployment '%s' in namespace '%s': %w", c.Name, namespace, err)
+		}
+		return deploymentStatus(d)
+	case "StatefulSet":
+		s, err := apps.StatefulSets(namespace).Get(ctx, c.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", false, fmt.Errorf("get statefulset '%s' in namespace '%s': %w", c.Name, namespace, err)
+		}
+		return statefulSetStatus(s)
+	case "DaemonSet":
+		d, err := apps.DaemonSets(namespace).Get(ctx, c.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", false, fmt.Errorf("get daemonset '%s' in namespace '%s': %w", c.Name, namespace, err)
+		}
+		return daemonSetStatus(d)
+	}
+	return "", false, fmt.Errorf("status of %s '%s': %w", c.Kind, c.Name, errRolloutNotSupported)
+}
+
+// WaitRollout polls rollout status until it is complete or context is done.
+func (k *K8SClient) WaitRollout(ctx context.Context, namespace string, c Consumer) (string, error) {
+	ticker := time.NewTicker(rolloutPollInterval)
+	defer ticker.Stop()
+	for {
+		msg, done, err := k.RolloutStatus(ctx, namespace, c)
+		if err != nil {
+			return "", err
+		}
+		if done {
+			return msg, nil
+		}
+		select {
+		case <-ctx.Done():
+			return msg, fmt.Errorf("wait for %s '%s' rollout: %w", c.Kind, c.Name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func deploymentStatus(d *appsv1.Deployment) (string, bool, error) {
+	if d.Generation > d.Status.ObservedGeneration {
+		return "waiting for deployment spec update to be observed", false, nil
+	}
+	for _, cond := range d.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded" {
+			return "", false, fmt.Errorf("deployment '%s' exceeded its progress deadline", d.Name)
+		}
+	}
+	if d.Spec.Replicas != nil && d.Status.UpdatedReplicas < *d.Spec.Replicas {
+		return fmt.Sprintf("%d out of %d new replicas have been updated",
+			d.Status.UpdatedReplicas, *d.Spec.Replicas), false, nil
+	}
+	if d.Status.Replicas > d.Status.UpdatedReplicas {
+		return fmt.Sprintf("%d old replicas are pending termination",
+			d.Status.Replicas-d.Status.UpdatedReplicas), false, nil
+	}
+	if d.Status.AvailableReplicas < d.Status.UpdatedReplicas {
+		return fmt.Sprintf("%d of %d updated replicas are available",
+			d.Status.AvailableReplicas, d.Status.UpdatedReplicas), false, nil
+	}
+	return "successfully rolled out", true, nil
+}
+
+func statefulSetStatus(s *appsv1.StatefulSet) (string, bool, error) {
+	if s.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
+		return "", false, fmt.Errorf("statefulset '%s' uses %s strategy: %w",
+			s.Name, s.Spec.UpdateStrategy.Type, errRolloutNotSupported)
+	}
+	if s.Status.ObservedGeneration == 0 || s.Generation > s.Status.ObservedGeneration {
+		return "waiting for statefulset spec update to be observed", false, nil
+	}
+	if s.Spec.Replicas != nil && s.Status.ReadyReplicas < *s.Spec.Replicas {
+		return fmt.Sprintf("%d of %d pods are ready",
+			s.Status.ReadyReplicas, *s.Spec.Replicas), false, nil
+	}
+	if s.Status.UpdateRevision != s.Status.CurrentRevision {
+		return fmt.Sprintf("%d pods at revision %s",
+			s.Status.UpdatedReplicas, s.Status.UpdateRevision), false, nil
+	}
+	return "successfully rolled out", true, nil
+}
+
+func daemonSetStatus(d *appsv1.DaemonSet) (string, bool, error) {
+	if d.Spec.UpdateStrategy.Type != appsv1.RollingUpdateDaemonSetStrategyType {
+		return "", false, fmt.Errorf("daemonset '%s' uses %s strategy: %w",
+			d.Name, d.Spec.UpdateStrategy.Type, errRolloutNotSupported)
+	}
+	if d.Generation > d.Status.ObservedGeneration {
+		return "waiting for daemonset spec update to be observed", false, nil
+	}
+	if d.Status.UpdatedNumberScheduled < d.Status.DesiredNumberScheduled {
+		return fmt.Sprintf("%d out of %d new pods have been updated",
+			d.Status.UpdatedNumberScheduled, d.Status.DesiredNumberScheduled), false, nil
+	}
+	if d.Status.NumberAvailable < d.Status.DesiredNumberScheduled {
+		return fmt.Sprintf("%d of %d updated pods are available",
+			d.Status.NumberAvailable, d.Status.DesiredNumberScheduled), false, nil
+	}
+	return "successfully rolled out", true, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRestartableConsumers(t *testing.T) {
+	consumers := []Consumer{
+		{Kind: "Pod", Name: "p"},
+		{Kind: "Deployment", Name: "d"},
+		{Kind: "StatefulSet", Name: "s"},
+		{Kind: "DaemonSet", Name: "ds"},
+		{Kind: "CronJob", Name: "c"},
+	}
+
+	restartable := RestartableConsumers(consumers)
+	if len(restartable) != 3 {
+		t.Fatalf("expected 3 restartable consumers, got %d", len(restartable))
+	}
+	for _, c := range restartable {
+		if c.Kind == "Pod" || c.Kind == "CronJob" {
+			t.Errorf("unexpected restartable consumer: %s", c.Kind)
+		}
+	}
+}
+
+func TestRestartWorkload(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}},
+	)
+	client := &K8SClient{clientset: fakeClientset}
+	ctx := context.Background()
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	err := client.RestartWorkload(ctx, "default", Consumer{Kind: "Deployment", Name: "web"}, at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d, err := fakeClientset.AppsV1().Deployments("default").Get(ctx, "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := d.Spec.Template.Annotations[restartedAtAnnotation]; v != "2024-01-02T03:04:05Z" {
+		t.Errorf("expected restartedAt annotation '2024-01-02T03:04:05Z', got '%s'", v)
+	}
+}
+
+func TestRestartWorkload_Unsupported(t *testing.T) {
+	client := &K8SClient{clientset: fake.NewSimpleClientset()}
+
+	err := client.RestartWorkload(context.Background(), "default", Consumer{Kind: "Pod", Name: "p"}, time.Now())
+	if err == nil {
+		t.Error("expected error for unsupported kind, got nil")
+	}
+}
+
+func TestDeploymentStatus(t *testing.T) {
+	replicas := int32(2)
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Generation: 2},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 1},
+	}
+
+	if _, done, err := deploymentStatus(d); err != nil || done {
+		t.Errorf("expected pending rollout for unobserved generation, got done=%v err=%v", done, err)
+	}
+
+	d.Status = appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 2}
+	if _, done, err := deploymentStatus(d); err != nil || done {
+		t.Errorf("expected pending rollout for old replicas, got done=%v err=%v", done, err)
+	}
+
+	d.Status = appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}
+	if _, done, err := deploymentStatus(d); err != nil || !done {
+		t.Errorf("expected complete rollout, got done=%v err=%v", done, err)
+	}
+
+	d.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type: appsv1.DeploymentProgressing, Reason: "ProgressDeadlineExceeded",
+	}}
+	if _, _, err := deploymentStatus(d); err == nil {
+		t.Error("expected error for exceeded progress deadline, got nil")
+	}
+}
+
+func TestStatefulSetStatus(t *testing.T) {
+	replicas := int32(1)
+	s := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Generation: 1},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:       &replicas,
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType},
+		},
+		Status: appsv1.StatefulSetStatus{
+			ObservedGeneration: 1, ReadyReplicas: 1, CurrentRevision: "a", UpdateRevision: "b",
+		},
+	}
+	if _, done, err := statefulSetStatus(s); err != nil || done {
+		t.Errorf("expected pending rollout for revision update, got done=%v err=%v", done, err)
+	}
+
+	s.Status.CurrentRevision = "b"
+	if _, done, err := statefulSetStatus(s); err != nil || !done {
+		t.Errorf("expected complete rollout, got done=%v err=%v", done, err)
+	}
+
+	s.Spec.UpdateStrategy.Type = appsv1.OnDeleteStatefulSetStrategyType
+	if _, _, err := statefulSetStatus(s); err == nil {
+		t.Error("expected error for OnDelete strategy, got nil")
+	}
+}
+
+func TestDaemonSetStatus(t *testing.T) {
+	d := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Generation: 1},
+		Spec: appsv1.DaemonSetSpec{
+			UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType},
+		},
+		Status: appsv1.DaemonSetStatus{
+			ObservedGeneration: 1, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 2,
+		},
+	}
+	if _, done, err := daemonSetStatus(d); err != nil || done {
+		t.Errorf("expected pending rollout for unavailable pods, got done=%v err=%v", done, err)
+	}
+
+	d.Status.NumberAvailable = 3
+	if _, done, err := daemonSetStatus(d); err != nil || !done {
+		t.Errorf("expected complete rollout, got done=%v err=%v", done, err)
+	}
+}
+
+func TestWaitRollout_Complete(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(
+		&appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "default"},
+			Spec: appsv1.DaemonSetSpec{
+				UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType},
+			},
+		},
+	)
+	client := &K8SClient{clientset: fakeClientset}
+
+	msg, err := client.WaitRollout(context.Background(), "default", Consumer{Kind: "DaemonSet", Name: "agent"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg != "successfully rolled out" {
+		t.Errorf("unexpected message: %s", msg)
+	}
+}
+
+func TestWaitRollout_Timeout(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(
+		&appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "default", Generation: 2},
+			Spec: appsv1.DaemonSetSpec{
+				UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType},
+			},
+		},
+	)
+	client := &K8SClient{clientset: fakeClientset}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := client.WaitRollout(ctx, "default", Consumer{Kind: "DaemonSet", Name: "agent"})
+	if err == nil {
+		t.Error("expected timeout error, got nil")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// rotatedAtAnnotation records time of the last key rotation.
+const rotatedAtAnnotation = "secctl.io/rotated-at"
+
+// defaultPreviousKeySuffix is appended to the key name to keep
+// the previous value during rotation.
+const defaultPreviousKeySuffix = "_previous"
+
+// RotatedData returns copy of data with the current value of key moved
+// to previousKey and the new value set to key.
+func RotatedData(data SecretData, key, previousKey string, value []byte) SecretData {
+	res := maps.Clone(data)
+	if res == nil {
+		res = make(SecretData)
+	}
+	res[previousKey] = data[key]
+	res[key] = value
+	return res
+}
+
+// MarkRotated records rotation time in annotation.
+func MarkRotated(meta *metav1.ObjectMeta, at time.Time) {
+	metav1.SetMetaDataAnnotation(meta, rotatedAtAnnotation, at.UTC().Format(time.RFC3339))
+}
+
+// RotateSecretKey moves the current value of key to previousKey, sets
+// the new value and records rotation time in annotation.
+func (k *K8SClient) RotateSecretKey(ctx context.Context, namespace, name, key, previousKey string, value []byte, at time.Time) error {
+	secret, err := k.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if _, ok := secret.Data[key]; !ok {
+		return fmt.Errorf("key '%s' not found in secret '%s' in namespace '%s'", key, name, namespace)
+	}
+	secret.Data = RotatedData(secret.Data, key, previousKey, value)
+	MarkRotated(&secret.ObjectMeta, at)
+	k.stamp(ctx, &secret.ObjectMeta, key, previousKey)
+
+	if _, err = k.clientset.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	return nil
+}
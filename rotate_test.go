@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRotatedData(t *testing.T) {
+	data := SecretData{"password": []byte("old"), "user": []byte("admin")}
+
+	res := RotatedData(data, "password", "password_previous", []byte("new"))
+	if string(res["password"]) != "new" || string(res["password_previous"]) != "old" || string(res["user"]) != "admin" {
+		t.Errorf("unexpected rotated data: %v", res)
+	}
+	if string(data["password"]) != "old" || len(data) != 2 {
+		t.Errorf("expected original data to be unchanged, got %v", data)
+	}
+}
+
+func TestRotateSecretKey(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("old")},
+	})
+	client := &K8SClient{clientset: fakeClientset}
+	ctx := context.Background()
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := client.RotateSecretKey(ctx, "default", "db", "password", "password_previous", []byte("new"), at); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secret, err := fakeClientset.CoreV1().Secrets("default").Get(ctx, "db", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(secret.Data["password"]) != "new" || string(secret.Data["password_previous"]) != "old" {
+		t.Errorf("unexpected data: %v", secret.Data)
+	}
+	if v := secret.Annotations[rotatedAtAnnotation]; v != "2024-03-01T12:00:00Z" {
+		t.Errorf("expected rotation annotation '2024-03-01T12:00:00Z', got '%s'", v)
+	}
+
+	if err := client.RotateSecretKey(ctx, "default", "db", "missing", "missing_previous", []byte("new"), at); err == nil {
+		t.Error("expected error for missing key, got nil")
+	}
+}
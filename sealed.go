@@ -0,0 +1,209 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// SealScope defines where a SealedSecret may be unsealed.
+type SealScope string
+
+const (
+	// SealStrict binds sealed data to the secret name and namespace.
+	SealStrict SealScope = "strict"
+	// SealNamespaceWide allows renaming the secret within its namespace.
+	SealNamespaceWide SealScope = "namespace-wide"
+	// SealClusterWide allows unsealing in any namespace with any name.
+	SealClusterWide SealScope = "cluster-wide"
+)
+
+// Annotations marking SealedSecret scope for the controller.
+const (
+	sealNamespaceWideAnnotation = "sealedsecrets.bitnami.com/namespace-wide"
+	sealClusterWideAnnotation   = "sealedsecrets.bitnami.com/cluster-wide"
+)
+
+// sealSessionKeySize is the AES-256 session key size used by kubeseal.
+const sealSessionKeySize = 32
+
+func (s SealScope) String() string {
+	return string(s)
+}
+
+// Set implements flag.Value.
+func (s *SealScope) Set(v string) error {
+	scope := SealScope(v)
+	if err := scope.Validate(); err != nil {
+		return err
+	}
+	*s = scope
+	return nil
+}
+
+// Validate checks that seal scope is known.
+func (s SealScope) Validate() error {
+	switch s {
+	case SealStrict, SealNamespaceWide, SealClusterWide:
+		return nil
+	}
+	return fmt.Errorf("unknown seal scope '%s', expected one of: %s, %s, %s",
+		s, SealStrict, SealNamespaceWide, SealClusterWide)
+}
+
+// label returns encryption label binding sealed data to the scope.
+func (s SealScope) label(namespace, name string) []byte {
+	switch s {
+	case SealNamespaceWide:
+		return []byte(namespace)
+	case SealClusterWide:
+		return nil
+	}
+	return []byte(namespace + "/" + name)
+}
+
+// SealedSecret is a bitnami.com/v1alpha1 SealedSecret manifest.
+type SealedSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              SealedSecretSpec `json:"spec"`
+}
+
+// SealedSecretSpec is the spec of SealedSecret.
+type SealedSecretSpec struct {
+	Template      SecretTemplate    `json:"template"`
+	EncryptedData map[string]string `json:"encryptedData"`
+}
+
+// SecretTemplate describes the Secret created by the controller.
+type SecretTemplate struct {
+	ObjectMeta metav1.ObjectMeta `json:"metadata"`
+	Type       corev1.SecretType `json:"type,omitempty"`
+	Immutable  *bool             `json:"immutable,omitempty"`
+}
+
+// LoadSealingKey reads RSA public key from the controller certificate,
+// as fetched with `kubeseal --fetch-cert`.
+func LoadSealingKey(p string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("read sealing certificate: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("parse sealing certificate '%s': no PEM certificate found", p)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse sealing certificate '%s': %w", p, err)
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("parse sealing certificate '%s': expected RSA public key", p)
+	}
+	return key, nil
+}
+
+// SealSecret encrypts secret data with the controller public key and
+// returns SealedSecret manifest. Secret metadata becomes the template.
+func SealSecret(secret *corev1.Secret, key *rsa.PublicKey, scope SealScope) ([]byte, error) {
+	label := scope.label(secret.Namespace, secret.Name)
+	encrypted := make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		ciphertext, err := hybridEncrypt(rand.Reader, key, v, label)
+		if err != nil {
+			return nil, fmt.Errorf("seal key '%s': %w", k, err)
+		}
+		encrypted[k] = base64.StdEncoding.EncodeToString(ciphertext)
+	}
+
+	annotations := make(map[string]string)
+	switch scope {
+	case SealNamespaceWide:
+		annotations[sealNamespaceWideAnnotation] = "true"
+	case SealClusterWide:
+		annotations[sealClusterWideAnnotation] = "true"
+	}
+	// manifest is committed, so it must not carry data in annotations
+	templateAnnotations := manifestAnnotations(secret.Annotations)
+	if len(annotations) == 0 {
+		annotations = nil
+	} else {
+		if templateAnnotations == nil {
+			templateAnnotations = make(map[string]string)
+		}
+		maps.Copy(templateAnnotations, annotations)
+	}
+
+	sealed := SealedSecret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "bitnami.com/v1alpha1", Kind: "SealedSecret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        secret.Name,
+			Namespace:   secret.Namespace,
+			Annotations: annotations,
+		},
+		Spec: SealedSecretSpec{
+			Template: SecretTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        secret.Name,
+					Namespace:   secret.Namespace,
+					Labels:      secret.Labels,
+					Annotations: templateAnnotations,
+				},
+				Type:      secret.Type,
+				Immutable: secret.Immutable,
+			},
+			EncryptedData: encrypted,
+		},
+	}
+	data, err := yaml.Marshal(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("marshal sealed secret: %w", err)
+	}
+	return data, nil
+}
+
+// hybridEncrypt encrypts plaintext the way Sealed Secrets does: random
+// AES-GCM session key encrypted with RSA-OAEP, prefixed by its length.
+func hybridEncrypt(rnd io.Reader, key *rsa.PublicKey, plaintext, label []byte) ([]byte, error) {
+	sessionKey := make([]byte, sealSessionKeySize)
+	if _, err := io.ReadFull(rnd, sessionKey); err != nil {
+		return nil, fmt.Errorf("generate session key: %w", err)
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	rsaCiphertext, err := rsa.EncryptOAEP(sha256.New(), rnd, key, sessionKey, label)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt session key: %w", err)
+	}
+	if len(rsaCiphertext) > 0xffff {
+		return nil, errors.New("encrypt session key: RSA key is too large")
+	}
+
+	ciphertext := binary.BigEndian.AppendUint16(nil, uint16(len(rsaCiphertext)))
+	ciphertext = append(ciphertext, rsaCiphertext...)
+	// session key is used only once, so zero nonce is fine
+	zeroNonce := make([]byte, gcm.NonceSize())
+	return gcm.Seal(ciphertext, zeroNonce, plaintext, nil), nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func writeTestSealingCert(t *testing.T) (string, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sealed-secret"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	p := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(p, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	return p, key
+}
+
+// hybridDecrypt reverses hybridEncrypt as Sealed Secrets controller does.
+func hybridDecrypt(t *testing.T, key *rsa.PrivateKey, ciphertext, label []byte) ([]byte, error) {
+	t.Helper()
+
+	n := int(binary.BigEndian.Uint16(ciphertext))
+	sessionKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, key, ciphertext[2:2+n], label)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, make([]byte, gcm.NonceSize()), ciphertext[2+n:], nil)
+}
+
+func TestSealSecret(t *testing.T) {
+	certPath, privateKey := writeTestSealingCert(t)
+	key, err := LoadSealingKey(certPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", Labels: map[string]string{"app": "db"}},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"password": []byte("secret123")},
+	}
+
+	tests := []struct {
+		scope      SealScope
+		label      string
+		annotation string
+	}{
+		{SealStrict, "default/db", ""},
+		{SealNamespaceWide, "default", sealNamespaceWideAnnotation},
+		{SealClusterWide, "", sealClusterWideAnnotation},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.scope), func(t *testing.T) {
+			data, err := SealSecret(secret, key, tt.scope)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var sealed SealedSecret
+			if err := yaml.UnmarshalStrict(data, &sealed); err != nil {
+				t.Fatalf("failed to parse sealed secret: %v", err)
+			}
+			if sealed.Kind != "SealedSecret" || sealed.Name != "db" || sealed.Namespace != "default" {
+				t.Errorf("unexpected sealed secret: %s %s/%s", sealed.Kind, sealed.Namespace, sealed.Name)
+			}
+			if sealed.Spec.Template.ObjectMeta.Labels["app"] != "db" || sealed.Spec.Template.Type != corev1.SecretTypeOpaque {
+				t.Errorf("unexpected template: %+v", sealed.Spec.Template)
+			}
+			if tt.annotation != "" && sealed.Annotations[tt.annotation] != "true" {
+				t.Errorf("expected annotation %s, got %v", tt.annotation, sealed.Annotations)
+			}
+
+			checkUnseal(t, privateKey, sealed.Spec.EncryptedData["password"], tt.label, tt.scope != SealClusterWide)
+		})
+	}
+}
+
+// checkUnseal checks that sealed value decrypts to 'secret123' with
+// label, and if bound, fails to decrypt with another label.
+func checkUnseal(t *testing.T, key *rsa.PrivateKey, sealed, label string, bound bool) {
+	t.Helper()
+
+	ciphertext, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		t.Fatalf("failed to decode encrypted data: %v", err)
+	}
+	plain, err := hybridDecrypt(t, key, ciphertext, []byte(label))
+	if err != nil {
+		t.Fatalf("failed to decrypt with label %q: %v", label, err)
+	}
+	if string(plain) != "secret123" {
+		t.Errorf("expected 'secret123', got '%s'", plain)
+	}
+	if bound {
+		if _, err := hybridDecrypt(t, key, ciphertext, []byte("other/db")); err == nil {
+			t.Error("expected decryption with other label to fail")
+		}
+	}
+}
+
+func TestSealSecret_LastAppliedConfig(t *testing.T) {
+	certPath, _ := writeTestSealingCert(t)
+	key, err := LoadSealingKey(certPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"note":                             "keep",
+				corev1.LastAppliedConfigAnnotation: `{"data":{"password":"c2VjcmV0MTIz"}}`,
+			},
+		},
+		Data: map[string][]byte{"password": []byte("secret123")},
+	}
+
+	data, err := SealSecret(secret, key, SealStrict)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var sealed SealedSecret
+	if err := yaml.UnmarshalStrict(data, &sealed); err != nil {
+		t.Fatalf("failed to parse sealed secret: %v", err)
+	}
+	annotations := sealed.Spec.Template.ObjectMeta.Annotations
+	if len(annotations) != 1 || annotations["note"] != "keep" {
+		t.Errorf("expected only 'note' template annotation, got %v", annotations)
+	}
+	if _, ok := secret.Annotations[corev1.LastAppliedConfigAnnotation]; !ok {
+		t.Error("expected secret annotations to be left unchanged")
+	}
+}
+
+func TestLoadSealingKey_Invalid(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(p, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := LoadSealingKey(p); err == nil {
+		t.Error("expected error for invalid certificate, got nil")
+	}
+}
+
+func TestSealScope_Set(t *testing.T) {
+	var s SealScope
+	if err := s.Set("cluster-wide"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s != SealClusterWide {
+		t.Errorf("expected 'cluster-wide', got '%s'", s)
+	}
+	if err := s.Set("global"); err == nil {
+		t.Error("expected error for unknown scope, got nil")
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxSecretSize is the limit of total size of secret and ConfigMap
+// data enforced by the API server.
+const maxSecretSize = 1 << 20
+
+// secretSizeWarn is the size after which saving the secret is warned
+// about, 90% of the limit.
+const secretSizeWarn = maxSecretSize * 9 / 10
+
+// KeySize is the size of the secret key value.
+type KeySize struct {
+	Key  string
+	Size int
+}
+
+// SecretSize returns total size of secret data as validated by the
+// API server, which is the sum of decoded values.
+func SecretSize(data SecretData) int {
+	var size int
+	for _, v := range data {
+		size += len(v)
+	}
+	return size
+}
+
+// LargestKeys returns up to n keys with the largest values.
+func LargestKeys(data SecretData, n int) []KeySize {
+	sizes := make([]KeySize, 0, len(data))
+	for k, v := range data {
+		sizes = append(sizes, KeySize{Key: k, Size: len(v)})
+	}
+	slices.SortFunc(sizes, func(a, b KeySize) int {
+		return cmp.Or(cmp.Compare(b.Size, a.Size), cmp.Compare(a.Key, b.Key))
+	})
+	return sizes[:min(n, len(sizes))]
+}
+
+// SizeCheck is the result of checking secret data against the limit.
+type SizeCheck struct {
+	Size    int
+	Largest []KeySize
+}
+
+// CheckSecretSize checks secret data size against the API server limit.
+func CheckSecretSize(data SecretData) SizeCheck {
+	return SizeCheck{Size: SecretSize(data), Largest: LargestKeys(data, 3)}
+}
+
+// Exceeded checks if the secret would be rejected by the API server.
+func (c SizeCheck) Exceeded() bool {
+	return c.Size > maxSecretSize
+}
+
+// NearLimit checks if the secret is close to the limit.
+func (c SizeCheck) NearLimit() bool {
+	return c.Size > secretSizeWarn
+}
+
+// Format describes secret size and its largest keys.
+func (c SizeCheck) Format() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Data size is %s of %s limit, largest keys:", formatSize(c.Size), formatSize(maxSecretSize))
+	for _, k := range c.Largest {
+		fmt.Fprintf(&sb, "\n  %s: %s", k.Key, formatSize(k.Size))
+	}
+	return sb.String()
+}
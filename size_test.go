@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLargestKeys(t *testing.T) {
+	data := SecretData{
+		"small":  []byte("a"),
+		"big":    bytes.Repeat([]byte("a"), 100),
+		"medium": bytes.Repeat([]byte("a"), 10),
+		"other":  bytes.Repeat([]byte("a"), 10),
+	}
+	largest := LargestKeys(data, 3)
+	expected := []KeySize{{"big", 100}, {"medium", 10}, {"other", 10}}
+	if len(largest) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, largest)
+	}
+	for i := range expected {
+		if largest[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, largest)
+		}
+	}
+	if n := len(LargestKeys(SecretData{"a": nil}, 3)); n != 1 {
+		t.Errorf("expected 1 key, got %d", n)
+	}
+}
+
+func TestCheckSecretSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		nearLimit bool
+		exceeded  bool
+	}{
+		{"small", 1024, false, false},
+		{"near limit", maxSecretSize - 1024, true, false},
+		{"at limit", maxSecretSize, true, false},
+		{"over limit", maxSecretSize + 1, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := CheckSecretSize(SecretData{
+				"ca.crt": make([]byte, tt.size-1),
+				"token":  []byte("a"),
+			})
+			if check.NearLimit() != tt.nearLimit {
+				t.Errorf("expected near limit %v, got %v", tt.nearLimit, check.NearLimit())
+			}
+			if check.Exceeded() != tt.exceeded {
+				t.Errorf("expected exceeded %v, got %v", tt.exceeded, check.Exceeded())
+			}
+		})
+	}
+}
+
+func TestSizeCheckFormat(t *testing.T) {
+	check := CheckSecretSize(SecretData{"ca.crt": make([]byte, 2048), "token": []byte("a")})
+	out := check.Format()
+	for _, expected := range []string{"2.0 KiB of 1.0 MiB", "ca.crt: 2.0 KiB", "token: 1 B"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+}
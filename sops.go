@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	goyaml "go.yaml.in/yaml/v3"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// sopsEncryptedRegex limits encryption to Secret data, so the rest
+	// of manifest stays readable in the repository.
+	sopsEncryptedRegex = "^(data|stringData)$"
+	// sopsVersion is the SOPS version written to file metadata.
+	sopsVersion = "3.9.0"
+	// sopsNonceSize is the AES-GCM nonce size used by SOPS.
+	sopsNonceSize = 32
+	sopsKeySize   = 32
+)
+
+var sopsValueRe = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// sopsMetadata is the "sops" section of encrypted file.
+type sopsMetadata struct {
+	Age            []sopsAgeKey `yaml:"age"`
+	LastModified   string       `yaml:"lastmodified"`
+	MAC            string       `yaml:"mac"`
+	EncryptedRegex string       `yaml:"encrypted_regex,omitempty"`
+	Version        string       `yaml:"version"`
+}
+
+type sopsAgeKey struct {
+	Recipient string `yaml:"recipient"`
+	Enc       string `yaml:"enc"`
+}
+
+// ParseAgeRecipients parses comma separated age recipients.
+func ParseAgeRecipients(s string) ([]*age.X25519Recipient, error) {
+	var res []*age.X25519Recipient
+	for _, r := range strings.Split(s, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		recipient, err := age.ParseX25519Recipient(r)
+		if err != nil {
+			return nil, fmt.Errorf("parse age recipient '%s': %w", r, err)
+		}
+		res = append(res, recipient)
+	}
+	if len(res) == 0 {
+		return nil, errors.New("no age recipients")
+	}
+	return res, nil
+}
+
+// LoadAgeIdentities loads age identities the same way SOPS does: from
+// $SOPS_AGE_KEY, $SOPS_AGE_KEY_FILE or sops/age/keys.txt in user config
+// directory.
+func LoadAgeIdentities() ([]age.Identity, error) {
+	if key := os.Getenv("SOPS_AGE_KEY"); key != "" {
+		return age.ParseIdentities(strings.NewReader(key))
+	}
+	p := os.Getenv("SOPS_AGE_KEY_FILE")
+	if p == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, fmt.Errorf("get config directory: %w", err)
+		}
+		p = filepath.Join(dir, "sops", "age", "keys.txt")
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("read age identities: %w", err)
+	}
+	defer f.Close()
+	ids, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse age identities '%s': %w", p, err)
+	}
+	return ids, nil
+}
+
+// EncryptSOPS encodes secret manifest as SOPS file with data and
+// stringData values encrypted for age recipients.
+func EncryptSOPS(secret *corev1.Secret, recipients []*age.X25519Recipient, now time.Time) ([]byte, error) {
+	manifest, err := yaml.Marshal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("encode secret: %w", err)
+	}
+	var doc goyaml.Node
+	if err := goyaml.Unmarshal(manifest, &doc); err != nil {
+		return nil, fmt.Errorf("encode secret: %w", err)
+	}
+	root := doc.Content[0]
+
+	dataKey := make([]byte, sopsKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("generate data key: %w", err)
+	}
+	encRegex := regexp.MustCompile(sopsEncryptedRegex)
+	hash := sha512.New()
+	err = walkSOPSTree(root, nil, func(node *goyaml.Node, path []string) error {
+		hash.Write([]byte(sopsScalarValue(node)))
+		if !sopsPathMatches(encRegex, path) || node.Value == "" {
+			return nil
+		}
+		enc, err := sopsEncrypt(node.Value, dataKey, strings.Join(path, ":")+":")
+		if err != nil {
+			return err
+		}
+		node.SetString(enc)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	meta := sopsMetadata{
+		LastModified:   now.UTC().Format(time.RFC3339),
+		EncryptedRegex: sopsEncryptedRegex,
+		Version:        sopsVersion,
+	}
+	if meta.MAC, err = sopsEncrypt(fmt.Sprintf("%X", hash.Sum(nil)), dataKey, meta.LastModified); err != nil {
+		return nil, err
+	}
+	for _, r := range recipients {
+		enc, err := encryptAgeKey(dataKey, r)
+		if err != nil {
+			return nil, err
+		}
+		meta.Age = append(meta.Age, sopsAgeKey{Recipient: r.String(), Enc: enc})
+	}
+	var metaNode goyaml.Node
+	if err := metaNode.Encode(meta); err != nil {
+		return nil, fmt.Errorf("encode sops metadata: %w", err)
+	}
+	root.Content = append(root.Content,
+		&goyaml.Node{Kind: goyaml.ScalarNode, Tag: "!!str", Value: "sops"}, &metaNode)
+
+	var buf bytes.Buffer
+	enc := goyaml.NewEncoder(&buf)
+	enc.SetIndent(4)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("encode sops file: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encode sops file: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecryptSOPS decrypts SOPS file with age identities, verifies its MAC
+// and returns the Secret manifest.
+func DecryptSOPS(data []byte, identities []age.Identity) (*corev1.Secret, error) {
+	var doc goyaml.Node
+	if err := goyaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse sops file: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != goyaml.MappingNode {
+		return nil, errors.New("parse sops file: expected YAML mapping")
+	}
+	root := doc.Content[0]
+
+	meta, err := extractSOPSMetadata(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := decryptSOPSTree(root, meta, identities); err != nil {
+		return nil, err
+	}
+	return sopsSecret(root)
+}
+
+// extractSOPSMetadata removes sops metadata from the document root
+// and returns it.
+func extractSOPSMetadata(root *goyaml.Node) (*sopsMetadata, error) {
+	for i := 0; i < len(root.Content); i += 2 {
+		if root.Content[i].Value != "sops" {
+			continue
+		}
+		meta := new(sopsMetadata)
+		if err := root.Content[i+1].Decode(meta); err != nil {
+			return nil, fmt.Errorf("parse sops metadata: %w", err)
+		}
+		root.Content = append(root.Content[:i], root.Content[i+2:]...)
+		return meta, nil
+	}
+	return nil, errors.New("file is not encrypted with SOPS: no sops metadata")
+}
+
+// decryptSOPSTree decrypts encrypted values of the tree in place and
+// verifies the MAC of all values.
+func decryptSOPSTree(root *goyaml.Node, meta *sopsMetadata, identities []age.Identity) error {
+	dataKey, err := decryptAgeKey(meta.Age, identities)
+	if err != nil {
+		return err
+	}
+	hash := sha512.New()
+	err = walkSOPSTree(root, nil, func(node *goyaml.Node, path []string) error {
+		if sopsValueRe.MatchString(node.Value) {
+			value, err := sopsDecrypt(node.Value, dataKey, strings.Join(path, ":")+":")
+			if err != nil {
+				return fmt.Errorf("decrypt '%s': %w", strings.Join(path, "."), err)
+			}
+			node.SetString(value)
+		}
+		hash.Write([]byte(sopsScalarValue(node)))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	mac, err := sopsDecrypt(meta.MAC, dataKey, meta.LastModified)
+	if err != nil {
+		return fmt.Errorf("decrypt MAC: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(mac), []byte(fmt.Sprintf("%X", hash.Sum(nil)))) != 1 {
+		return errors.New("MAC mismatch: file was modified after encryption")
+	}
+	return nil
+}
+
+// sopsSecret decodes decrypted tree as Secret manifest with stringData
+// merged into data.
+func sopsSecret(root *goyaml.Node) (*corev1.Secret, error) {
+	manifest, err := goyaml.Marshal(root)
+	if err != nil {
+		return nil, fmt.Errorf("encode secret: %w", err)
+	}
+	var secret corev1.Secret
+	if err := yaml.Unmarshal(manifest, &secret); err != nil {
+		return nil, fmt.Errorf("parse secret: %w", err)
+	}
+	if secret.Kind != "Secret" || secret.Name == "" {
+		return nil, fmt.Errorf("expected Secret manifest with name, got kind '%s' name '%s'", secret.Kind, secret.Name)
+	}
+	if len(secret.StringData) > 0 && secret.Data == nil {
+		secret.Data = make(map[string][]byte, len(secret.StringData))
+	}
+	for k, v := range secret.StringData {
+		secret.Data[k] = []byte(v)
+	}
+	secret.StringData = nil
+	return &secret, nil
+}
+
+// walkSOPSTree calls fn for every scalar of the tree in document order
+// with the path of mapping keys leading to it. Sequence items share
+// the path of the sequence, as in SOPS.
+func walkSOPSTree(node *goyaml.Node, path []string, fn func(*goyaml.Node, []string) error) error {
+	switch node.Kind {
+	case goyaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if err := walkSOPSTree(node.Content[i+1], append(path[:len(path):len(path)], key), fn); err != nil {
+				return err
+			}
+		}
+	case goyaml.SequenceNode:
+		for _, item := range node.Content {
+			if err := walkSOPSTree(item, path, fn); err != nil {
+				return err
+			}
+		}
+	case goyaml.ScalarNode:
+		return fn(node, path)
+	}
+	return nil
+}
+
+func sopsPathMatches(re *regexp.Regexp, path []string) bool {
+	for _, p := range path {
+		if re.MatchString(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// sopsScalarValue returns value bytes hashed into MAC the way SOPS
+// formats them.
+func sopsScalarValue(node *goyaml.Node) string {
+	if node.Tag == "!!bool" {
+		if strings.EqualFold(node.Value, "true") {
+			return "True"
+		}
+		return "False"
+	}
+	return node.Value
+}
+
+func sopsEncrypt(value string, key []byte, aad string) (string, error) {
+	gcm, err := newSOPSCipher(key, sopsNonceSize)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, sopsNonceSize)
+	if _, err := rand.Read(iv); err != nil {
+		return "", fmt.Errorf("generate iv: %w", err)
+	}
+	out := gcm.Seal(nil, iv, []byte(value), []byte(aad))
+	tagStart := len(out) - gcm.Overhead()
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:str]",
+		base64.StdEncoding.EncodeToString(out[:tagStart]),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(out[tagStart:])), nil
+}
+
+func sopsDecrypt(value string, key []byte, aad string) (string, error) {
+	m := sopsValueRe.FindStringSubmatch(value)
+	if m == nil {
+		return "", errors.New("invalid encrypted value")
+	}
+	var parts [3][]byte
+	for i, s := range m[1:4] {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return "", fmt.Errorf("decode encrypted value: %w", err)
+		}
+		parts[i] = b
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+	gcm, err := newSOPSCipher(key, len(iv))
+	if err != nil {
+		return "", err
+	}
+	plain, err := gcm.Open(nil, iv, append(data, tag...), []byte(aad))
+	if err != nil {
+		return "", fmt.Errorf("decrypt value: %w", err)
+	}
+	return string(plain), nil
+}
+
+func newSOPSCipher(key []byte, nonceSize int) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, nonceSize)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	return gcm, nil
+}
+
+func encryptAgeKey(dataKey []byte, recipient age.Recipient) (string, error) {
+	var buf bytes.Buffer
+	aw := armor.NewWriter(&buf)
+	w, err := age.Encrypt(aw, recipient)
+	if err != nil {
+		return "", fmt.Errorf("encrypt data key: %w", err)
+	}
+	if _, err := w.Write(dataKey); err != nil {
+		return "", fmt.Errorf("encrypt data key: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("encrypt data key: %w", err)
+	}
+	if err := aw.Close(); err != nil {
+		return "", fmt.Errorf("encrypt data key: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func decryptAgeKey(keys []sopsAgeKey, identities []age.Identity) ([]byte, error) {
+	for _, k := range keys {
+		r, err := age.Decrypt(armor.NewReader(strings.NewReader(k.Enc)), identities...)
+		if err != nil {
+			continue
+		}
+		dataKey, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt data key: %w", err)
+		}
+		return dataKey, nil
+	}
+	return nil, errors.New("decrypt data key: no matching age identity")
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func newTestSOPSFile(t *testing.T) ([]byte, *age.X25519Identity) {
+	t.Helper()
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate age identity: %v", err)
+	}
+	secret := SecretManifest(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", Labels: map[string]string{"app": "db"}},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"password": []byte("secret123"), "empty": {}},
+	})
+	data, err := EncryptSOPS(secret, []*age.X25519Recipient{identity.Recipient()}, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return data, identity
+}
+
+func TestEncryptSOPS(t *testing.T) {
+	data, identity := newTestSOPSFile(t)
+	out := string(data)
+
+	if strings.Contains(out, "c2VjcmV0MTIz") {
+		t.Errorf("expected data to be encrypted, got:\n%s", out)
+	}
+	for _, expected := range []string{
+		"name: db",
+		"password: ENC[AES256_GCM,data:",
+		"recipient: " + identity.Recipient().String(),
+		"-----BEGIN AGE ENCRYPTED FILE-----",
+		"encrypted_regex: ^(data|stringData)$",
+		"mac: ENC[AES256_GCM,",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+}
+
+func TestEncryptSOPS_LastAppliedConfig(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate age identity: %v", err)
+	}
+	secret := SecretManifest(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "db",
+			Namespace:   "default",
+			Annotations: map[string]string{corev1.LastAppliedConfigAnnotation: `{"data":{"password":"c2VjcmV0MTIz"}}`},
+		},
+		Data: map[string][]byte{"password": []byte("secret123")},
+	})
+	data, err := EncryptSOPS(secret, []*age.X25519Recipient{identity.Recipient()}, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out := string(data); strings.Contains(out, "c2VjcmV0MTIz") || strings.Contains(out, "last-applied-configuration") {
+		t.Errorf("expected secret data to be absent from plain text, got:\n%s", out)
+	}
+}
+
+func TestDecryptSOPS(t *testing.T) {
+	data, identity := newTestSOPSFile(t)
+
+	secret, err := DecryptSOPS(data, []age.Identity{identity})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if secret.Name != "db" || secret.Namespace != "default" || secret.Labels["app"] != "db" {
+		t.Errorf("unexpected metadata: %+v", secret.ObjectMeta)
+	}
+	if string(secret.Data["password"]) != "secret123" {
+		t.Errorf("expected password 'secret123', got '%s'", secret.Data["password"])
+	}
+	if v, ok := secret.Data["empty"]; !ok || len(v) != 0 {
+		t.Errorf("expected empty key to be kept, got %q", v)
+	}
+}
+
+func TestDecryptSOPS_WrongIdentity(t *testing.T) {
+	data, _ := newTestSOPSFile(t)
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate age identity: %v", err)
+	}
+	if _, err := DecryptSOPS(data, []age.Identity{other}); err == nil {
+		t.Error("expected error for wrong identity, got nil")
+	}
+}
+
+func TestDecryptSOPS_Tampered(t *testing.T) {
+	data, identity := newTestSOPSFile(t)
+	tampered := bytes.Replace(data, []byte("namespace: default"), []byte("namespace: prod"), 1)
+
+	_, err := DecryptSOPS(tampered, []age.Identity{identity})
+	if err == nil || !strings.Contains(err.Error(), "MAC mismatch") {
+		t.Errorf("expected MAC mismatch error, got %v", err)
+	}
+}
+
+func TestDecryptSOPS_NotEncrypted(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate age identity: %v", err)
+	}
+	if _, err := DecryptSOPS([]byte("kind: Secret\n"), []age.Identity{identity}); err == nil {
+		t.Error("expected error for plain file, got nil")
+	}
+}
+
+func TestParseAgeRecipients(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate age identity: %v", err)
+	}
+	r := identity.Recipient().String()
+
+	recipients, err := ParseAgeRecipients(r + ", " + r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recipients) != 2 {
+		t.Errorf("expected 2 recipients, got %d", len(recipients))
+	}
+	for _, s := range []string{"", "age1invalid"} {
+		if _, err := ParseAgeRecipients(s); err == nil {
+			t.Errorf("expected error for %q, got nil", s)
+		}
+	}
+}
+
+// runSOPS runs sops CLI in dir, the test is skipped if it's not installed.
+func runSOPS(t *testing.T, dir string, env []string, args ...string) []byte {
+	t.Helper()
+
+	bin, err := exec.LookPath("sops")
+	if err != nil {
+		// CI installs sops, so interop must not be skipped there
+		if os.Getenv("CI") != "" {
+			t.Fatal("sops is not installed")
+		}
+		t.Skip("sops is not installed")
+	}
+	cmd := exec.Command(bin, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("sops %s failed: %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return out
+}
+
+func TestEncryptSOPS_DecryptedBySOPS(t *testing.T) {
+	data, identity := newTestSOPSFile(t)
+	dir := t.TempDir()
+	p := filepath.Join(dir, "secret.yaml")
+	if err := os.WriteFile(p, data, 0o600); err != nil {
+		t.Fatalf("failed to write sops file: %v", err)
+	}
+
+	out := runSOPS(t, dir, []string{"SOPS_AGE_KEY=" + identity.String()},
+		"--decrypt", "--input-type", "yaml", "--output-type", "yaml", p)
+	var secret corev1.Secret
+	if err := yaml.Unmarshal(out, &secret); err != nil {
+		t.Fatalf("failed to parse sops output: %v", err)
+	}
+	if secret.Name != "db" || string(secret.Data["password"]) != "secret123" {
+		t.Errorf("unexpected secret decrypted by sops: %+v", secret)
+	}
+}
+
+func TestDecryptSOPS_EncryptedBySOPS(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate age identity: %v", err)
+	}
+	manifest := "apiVersion: v1\nkind: Secret\nmetadata:\n    name: db\n    namespace: default\n" +
+		"type: Opaque\ndata:\n    password: c2VjcmV0MTIz\nstringData:\n    token: abc\n"
+	dir := t.TempDir()
+	p := filepath.Join(dir, "secret.yaml")
+	if err := os.WriteFile(p, []byte(manifest), 0o600); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+
+	data := runSOPS(t, dir, nil, "--encrypt", "--age", identity.Recipient().String(),
+		"--encrypted-regex", sopsEncryptedRegex, "--input-type", "yaml", "--output-type", "yaml", p)
+	secret, err := DecryptSOPS(data, []age.Identity{identity})
+	if err != nil {
+		t.Fatalf("failed to decrypt file encrypted by sops: %v\n%s", err, data)
+	}
+	if string(secret.Data["password"]) != "secret123" || string(secret.Data["token"]) != "abc" {
+		t.Errorf("unexpected data: %v", secret.Data)
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// Key change kinds.
+const (
+	KeyAdded   = "+"
+	KeyRemoved = "-"
+	KeyChanged = "~"
+)
+
+// KeyChange is a change of a single secret key.
+type KeyChange struct {
+	Key  string
+	Kind string
+	Old  []byte
+	New  []byte
+}
+
+// DiffSecretData compares secret data and returns changes sorted by key.
+func DiffSecretData(prev, next SecretData) []KeyChange {
+	var changes []KeyChange
+	for k, v := range next {
+		old, ok := prev[k]
+		switch {
+		case !ok:
+			changes = append(changes, KeyChange{Key: k, Kind: KeyAdded, New: v})
+		case !slices.Equal(old, v):
+			changes = append(changes, KeyChange{Key: k, Kind: KeyChanged, Old: old, New: v})
+		}
+	}
+	for k, v := range prev {
+		if _, ok := next[k]; !ok {
+			changes = append(changes, KeyChange{Key: k, Kind: KeyRemoved, Old: v})
+		}
+	}
+	slices.SortFunc(changes, func(a, b KeyChange) int { return strings.Compare(a.Key, b.Key) })
+	return changes
+}
+
+// Format renders change with masked values.
+func (c KeyChange) Format(mask MaskMode) string {
+	switch c.Kind {
+	case KeyAdded:
+		return fmt.Sprintf("%s %s: %s", c.Kind, c.Key, mask.Mask(c.New))
+	case KeyRemoved:
+		return fmt.Sprintf("%s %s", c.Kind, c.Key)
+	}
+	return fmt.Sprintf("%s %s: %s -> %s", c.Kind, c.Key, mask.Mask(c.Old), mask.Mask(c.New))
+}
+
+// LastFieldManager returns the manager and operation of the most recent
+// managed fields entry, e.g. "kubectl-edit (Update)".
+func LastFieldManager(secret *corev1.Secret) string {
+	var last *metav1.ManagedFieldsEntry
+	for i := range secret.ManagedFields {
+		entry := &secret.ManagedFields[i]
+		if entry.Time == nil {
+			continue
+		}
+		if last == nil || !entry.Time.Before(last.Time) {
+			last = entry
+		}
+	}
+	if last == nil {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s (%s)", last.Manager, last.Operation)
+}
+
+// SecretEvent is a change of the watched secret.
+type SecretEvent struct {
+	Type    watch.EventType
+	Secret  *corev1.Secret
+	Changes []KeyChange
+}
+
+// Format renders event as a timestamped change summary.
+func (e SecretEvent) Format(at time.Time, mask MaskMode) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s", at.Format(time.RFC3339), e.Type)
+	if e.Type == watch.Deleted {
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, " by %s", LastFieldManager(e.Secret))
+	if len(e.Changes) == 0 {
+		sb.WriteString("\n  no data changes")
+	}
+	for _, c := range e.Changes {
+		sb.WriteString("\n  ")
+		sb.WriteString(c.Format(mask))
+	}
+	return sb.String()
+}
+
+// Delays between reconnects of a watch closed without events.
+const (
+	watchRetryMin = time.Second
+	watchRetryMax = 30 * time.Second
+)
+
+// WatchSecret calls fn for every change of the secret until context is done.
+// Watch is re-established from the last seen resource version when the
+// server closes it, with a growing delay if it closes without events.
+// When the version is too old to resume from, the secret is read again
+// and changes missed in between are reported as one event.
+func (k *K8SClient) WatchSecret(ctx context.Context, namespace, name string, fn func(SecretEvent)) error {
+	secrets := k.clientset.CoreV1().Secrets(namespace)
+	current, err := secrets.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get secret '%s' in namespace '%s': %w", name, namespace, err)
+	}
+	sw := &secretWatch{
+		secrets:         secrets,
+		namespace:       namespace,
+		name:            name,
+		fn:              fn,
+		exists:          true,
+		data:            current.Data,
+		objectVersion:   current.ResourceVersion,
+		resourceVersion: current.ResourceVersion,
+	}
+
+	delay := watchRetryMin
+	for {
+		received, err := sw.watch(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if received {
+			delay = watchRetryMin
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, watchRetryMax)
+	}
+}
+
+// secretWatch is the state of WatchSecret: the last seen secret and
+// version to resume watch from.
+type secretWatch struct {
+	secrets   typedcorev1.SecretInterface
+	namespace string
+	name      string
+	fn        func(SecretEvent)
+
+	exists bool
+	data   SecretData
+	// objectVersion is the resource version of the last seen secret.
+	objectVersion   string
+	resourceVersion string
+}
+
+// watch runs a single watch until the server closes it and reports if
+// any events were received. Expired watch is resynced.
+func (w *secretWatch) watch(ctx context.Context) (bool, error) {
+	wi, err := w.secrets.Watch(ctx, metav1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", w.name).String(),
+		ResourceVersion: w.resourceVersion,
+	})
+	if err != nil {
+		return false, fmt.Errorf("watch secret '%s' in namespace '%s': %w", w.name, w.namespace, err)
+	}
+	defer wi.Stop()
+
+	var received bool
+	for ev := range wi.ResultChan() {
+		switch ev.Type {
+		case watch.Added, watch.Modified, watch.Deleted:
+		case watch.Error:
+			err := apierrors.FromObject(ev.Object)
+			if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+				return true, w.resync(ctx)
+			}
+			return false, fmt.Errorf("watch secret '%s' in namespace '%s': %w", w.name, w.namespace, err)
+		default:
+			continue
+		}
+		if secret, ok := ev.Object.(*corev1.Secret); ok {
+			received = true
+			w.resourceVersion = secret.ResourceVersion
+			w.notify(ev.Type, secret)
+		}
+	}
+	return received, nil
+}
+
+// resync reads the secret and reports changes made since the last
+// seen version.
+func (w *secretWatch) resync(ctx context.Context) error {
+	list, err := w.secrets.List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", w.name).String(),
+	})
+	if err != nil {
+		return fmt.Errorf("list secret '%s' in namespace '%s': %w", w.name, w.namespace, err)
+	}
+	w.resourceVersion = list.ResourceVersion
+	switch {
+	case len(list.Items) == 0 && w.exists:
+		w.notify(watch.Deleted, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: w.name, Namespace: w.namespace}})
+	case len(list.Items) == 0:
+	case !w.exists:
+		w.notify(watch.Added, &list.Items[0])
+	case list.Items[0].ResourceVersion != w.objectVersion:
+		w.notify(watch.Modified, &list.Items[0])
+	}
+	return nil
+}
+
+// notify remembers the secret and calls fn with its changes.
+func (w *secretWatch) notify(t watch.EventType, secret *corev1.Secret) {
+	event := SecretEvent{Type: t, Secret: secret}
+	if t == watch.Deleted {
+		w.exists, w.data = false, nil
+	} else {
+		event.Changes = DiffSecretData(w.data, secret.Data)
+		w.exists, w.data = true, secret.Data
+	}
+	w.objectVersion = secret.ResourceVersion
+	w.fn(event)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestDiffSecretData(t *testing.T) {
+	prev := SecretData{"same": []byte("1"), "changed": []byte("old"), "removed": []byte("x")}
+	next := SecretData{"same": []byte("1"), "changed": []byte("new"), "added": []byte("y")}
+
+	changes := DiffSecretData(prev, next)
+	expected := []struct{ key, kind string }{
+		{"added", KeyAdded},
+		{"changed", KeyChanged},
+		{"removed", KeyRemoved},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %d: %v", len(expected), len(changes), changes)
+	}
+	for i, e := range expected {
+		if changes[i].Key != e.key || changes[i].Kind != e.kind {
+			t.Errorf("expected change %s %s, got %s %s", e.kind, e.key, changes[i].Kind, changes[i].Key)
+		}
+	}
+}
+
+func TestKeyChange_Format(t *testing.T) {
+	tests := []struct {
+		change   KeyChange
+		expected string
+	}{
+		{KeyChange{Key: "a", Kind: KeyAdded, New: []byte("secret123")}, "+ a: se****23 (9 bytes)"},
+		{KeyChange{Key: "b", Kind: KeyRemoved, Old: []byte("secret123")}, "- b"},
+		{KeyChange{Key: "c", Kind: KeyChanged, Old: []byte("x"), New: []byte("secret123")}, "~ c: <1 bytes hidden> -> se****23 (9 bytes)"},
+	}
+	for _, tt := range tests {
+		if res := tt.change.Format(MaskPartial); res != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, res)
+		}
+	}
+}
+
+func TestLastFieldManager(t *testing.T) {
+	older := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	newer := metav1.NewTime(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{ManagedFields: []metav1.ManagedFieldsEntry{
+		{Manager: "kubectl-edit", Operation: metav1.ManagedFieldsOperationUpdate, Time: &newer},
+		{Manager: "helm", Operation: metav1.ManagedFieldsOperationApply, Time: &older},
+	}}}
+	if res := LastFieldManager(secret); res != "kubectl-edit (Update)" {
+		t.Errorf("expected 'kubectl-edit (Update)', got '%s'", res)
+	}
+	if res := LastFieldManager(&corev1.Secret{}); res != "unknown" {
+		t.Errorf("expected 'unknown', got '%s'", res)
+	}
+}
+
+func TestSecretEvent_Format(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	ev := SecretEvent{
+		Type:    watch.Modified,
+		Secret:  &corev1.Secret{},
+		Changes: []KeyChange{{Key: "password", Kind: KeyChanged, Old: []byte("a"), New: []byte("b")}},
+	}
+	expected := "2024-03-01T12:00:00Z MODIFIED by unknown\n  ~ password: <1 bytes hidden> -> <1 bytes hidden>"
+	if res := ev.Format(at, MaskFull); res != expected {
+		t.Errorf("expected %q, got %q", expected, res)
+	}
+
+	ev.Changes = nil
+	expected = "2024-03-01T12:00:00Z MODIFIED by unknown\n  no data changes"
+	if res := ev.Format(at, MaskFull); res != expected {
+		t.Errorf("expected %q, got %q", expected, res)
+	}
+}
+
+func TestWatchSecret(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", ResourceVersion: "1"},
+		Data:       map[string][]byte{"password": []byte("old")},
+	}
+	fakeClientset := fake.NewSimpleClientset(secret)
+	fw := watch.NewFakeWithChanSize(2, false)
+	fakeClientset.PrependWatchReactor("secrets", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		return true, fw, nil
+	})
+
+	modified := secret.DeepCopy()
+	modified.ResourceVersion = "2"
+	modified.Data = map[string][]byte{"password": []byte("new"), "user": []byte("admin")}
+	fw.Modify(modified)
+	fw.Delete(modified)
+
+	client := &K8SClient{clientset: fakeClientset}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var events []SecretEvent
+	err := client.WatchSecret(ctx, "default", "db", func(ev SecretEvent) {
+		events = append(events, ev)
+		if len(events) == 2 {
+			cancel()
+			fw.Stop()
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Type != watch.Modified || len(events[0].Changes) != 2 {
+		t.Errorf("expected modified event with 2 changes, got %s with %v", events[0].Type, events[0].Changes)
+	}
+	if events[1].Type != watch.Deleted {
+		t.Errorf("expected deleted event, got %s", events[1].Type)
+	}
+}
+
+func TestWatchSecret_Expired(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", ResourceVersion: "1"},
+		Data:       map[string][]byte{"password": []byte("old")},
+	}
+	fakeClientset := fake.NewSimpleClientset(secret)
+	expired := watch.NewFakeWithChanSize(1, false)
+	expired.Error(&apierrors.NewResourceExpired("too old resource version").ErrStatus)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var versions []string
+	fakeClientset.PrependWatchReactor("secrets", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		versions = append(versions, action.(k8stesting.WatchAction).GetWatchRestrictions().ResourceVersion)
+		if len(versions) == 1 {
+			return true, expired, nil
+		}
+		// stop after the watch is resumed
+		cancel()
+		resumed := watch.NewFake()
+		resumed.Stop()
+		return true, resumed, nil
+	})
+
+	// the secret changes while watch is expired
+	modified := secret.DeepCopy()
+	modified.ResourceVersion = "5"
+	modified.Data["password"] = []byte("new")
+	fakeClientset.PrependReactor("list", "secrets", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &corev1.SecretList{
+			ListMeta: metav1.ListMeta{ResourceVersion: "7"},
+			Items:    []corev1.Secret{*modified},
+		}, nil
+	})
+
+	client := &K8SClient{clientset: fakeClientset}
+	var events []SecretEvent
+	err := client.WatchSecret(ctx, "default", "db", func(ev SecretEvent) {
+		events = append(events, ev)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != watch.Modified || len(events[0].Changes) != 1 {
+		t.Fatalf("expected modified event with 1 change, got %+v", events)
+	}
+	if !slices.Equal(versions, []string{"1", "7"}) {
+		t.Errorf("expected watch to resume from listed version, got %v", versions)
+	}
+}